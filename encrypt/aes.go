@@ -4,10 +4,8 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
 	"encoding/base64"
 	"errors"
-	"io"
 )
 
 const (
@@ -313,76 +311,109 @@ func (x *ecbDecrypter) CryptBlocks(dst, src []byte) {
 }
 
 // AesGcm aes-gcm加密解密
+//
+// 密文格式为 nonce(12) | 密文 | tag(16)，Key 为16、24或32字节时分别使用 AES-128、AES-192、AES-256
 type AesGcm struct {
 	// Key 加密密钥
 	Key []byte
 }
 
-// Encrypt AES GCM模式加密
+// NewAesGcm 创建 AES GCM 加密器
+//
+// @Description: 校验密钥长度
+// @param key 16、24或32字节密钥
+// @return *AesGcm
+// @return error 密钥长度不合法
+func NewAesGcm(key []byte) (*AesGcm, error) {
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	return &AesGcm{Key: key}, nil
+}
+
+// EncryptBytes AES GCM模式加密
 //
 // @Description: AES GCM模式加密
-// @param origData 原始数据
-// @return string 加密后的数据
+// @param plaintext 原始数据
+// @param aad 附加认证数据，如记录 ID，解密时须一致，可省略
+// @return []byte 加密后的数据
 // @return error
-func (a *AesGcm) Encrypt(origData string) (string, error) {
-	if origData == "" {
-		return "", errors.New("加密数据不能为空")
-	}
-	block, err := aes.NewCipher(a.Key)
-	if err != nil {
-		return "", err
-	}
+func (a *AesGcm) EncryptBytes(plaintext []byte, aad ...[]byte) ([]byte, error) {
+	return gcmSeal(a.Key, plaintext, joinAAD(aad))
+}
 
-	// 创建一个GCM模式的加密器
-	aesGCM, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
+// DecryptBytes AES GCM模式解密
+//
+// @Description: AES GCM模式解密
+// @param ciphertext EncryptBytes 加密后的数据
+// @param aad 附加认证数据
+// @return []byte 解密后的数据
+// @return error
+func (a *AesGcm) DecryptBytes(ciphertext []byte, aad ...[]byte) ([]byte, error) {
+	return gcmOpen(a.Key, ciphertext, joinAAD(aad))
+}
 
-	// 创建一个随机nonce
-	nonce := make([]byte, aesGCM.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+// EncryptString AES GCM模式加密，结果为标准 base64
+//
+// @Description: AES GCM模式加密
+// @param plaintext 原始数据
+// @param aad 附加认证数据，可省略
+// @return string 加密后的 base64 字符串
+// @return error
+func (a *AesGcm) EncryptString(plaintext string, aad ...[]byte) (string, error) {
+	out, err := a.EncryptBytes([]byte(plaintext), aad...)
+	if err != nil {
 		return "", err
 	}
-
-	// 加密数据
-	out := aesGCM.Seal(nil, nonce, []byte(origData), nil)
-	return base64.StdEncoding.EncodeToString(append(nonce, out...)), nil
+	return base64.StdEncoding.EncodeToString(out), nil
 }
 
-// Decrypt AES GCM模式解密
+// DecryptString AES GCM模式解密 EncryptString 的结果
 //
 // @Description: AES GCM模式解密
-// @param crypted 加密后的数据
+// @param ciphertext 加密后的 base64 字符串
+// @param aad 附加认证数据
 // @return string 解密后的数据
 // @return error
-func (a *AesGcm) Decrypt(crypted []byte) (string, error) {
-	if len(a.Key) != 16 {
-		return "", errors.New("key must be 16 bytes for AES-128")
-	}
-
-	block, err := aes.NewCipher(a.Key)
+func (a *AesGcm) DecryptString(ciphertext string, aad ...[]byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return "", err
 	}
-
-	aesGCM, err := cipher.NewGCM(block)
+	out, err := a.DecryptBytes(data, aad...)
 	if err != nil {
 		return "", err
 	}
+	return string(out), nil
+}
 
-	nonceSize := aesGCM.NonceSize()
-	if len(crypted) < nonceSize {
-		return "", errors.New("ciphertext too short")
+// Encrypt AES GCM模式加密
+//
+// Deprecated: 与 Decrypt 的输入格式不对称，请使用 EncryptString
+//
+// @Description: AES GCM模式加密
+// @param origData 原始数据
+// @return string 加密后的数据
+// @return error
+func (a *AesGcm) Encrypt(origData string) (string, error) {
+	if origData == "" {
+		return "", errors.New("加密数据不能为空")
 	}
+	return a.EncryptString(origData)
+}
 
-	nonce := crypted[:nonceSize]
-	ciphertext := crypted[nonceSize:]
-
-	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
+// Decrypt AES GCM模式解密
+//
+// Deprecated: 输入为 base64 解码后的数据，请使用 DecryptString 或 DecryptBytes
+//
+// @Description: AES GCM模式解密
+// @param crypted 加密后的数据
+// @return string 解密后的数据
+// @return error
+func (a *AesGcm) Decrypt(crypted []byte) (string, error) {
+	out, err := a.DecryptBytes(crypted)
 	if err != nil {
 		return "", err
 	}
-
-	return string(plaintext), nil
+	return string(out), nil
 }
@@ -0,0 +1,464 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CipherAlgorithm 信封中记录的加密算法
+type CipherAlgorithm uint8
+
+// 支持的加密算法，数值写入二进制信封，不可修改
+const (
+	AlgAesGcm      CipherAlgorithm = 1
+	AlgAesCbcPKCS7 CipherAlgorithm = 2
+	AlgAesCbcZero  CipherAlgorithm = 3
+	AlgAesEcbPKCS7 CipherAlgorithm = 4
+	AlgAesEcbZero  CipherAlgorithm = 5
+)
+
+// algorithmNames 文本信封中的算法名称
+var algorithmNames = map[CipherAlgorithm]string{
+	AlgAesGcm:      "AES-GCM",
+	AlgAesCbcPKCS7: "AES-CBC-PKCS7",
+	AlgAesCbcZero:  "AES-CBC-ZERO",
+	AlgAesEcbPKCS7: "AES-ECB-PKCS7",
+	AlgAesEcbZero:  "AES-ECB-ZERO",
+}
+
+// String 算法名称
+func (a CipherAlgorithm) String() string {
+	if name, ok := algorithmNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("CipherAlgorithm(%d)", a)
+}
+
+const (
+	// EnvelopeVersion 信封格式版本
+	EnvelopeVersion = 1
+
+	// envelopeMagic 二进制信封魔数，避免把旧版 API 输出的原始密文误当作信封解析
+	envelopeMagic = "tke"
+	// envelopeTextPrefix 文本信封前缀
+	envelopeTextPrefix = "$tke$"
+)
+
+// ErrUnsupportedEnvelope 信封版本或算法不支持
+var ErrUnsupportedEnvelope = errors.New("encrypt: unsupported envelope")
+
+// envelopeEncoding 文本信封中字段的编码
+var envelopeEncoding = base64.RawURLEncoding
+
+// Envelope 自描述的密文信封
+//
+// 记录版本、算法、密钥 ID、nonce/IV、认证标签和密文，解密时据此选择算法和密钥，
+// 因此更换算法或密钥后旧数据仍可解密。AES-GCM 同时认证信封头部，见 authData。
+// 二进制格式："tke" | 版本(1) | 算法(1) | 密钥ID长度(1) | 密钥ID | 包装密钥长度(2) | 包装密钥 | nonce长度(1) | nonce | tag长度(1) | tag | 密文；
+// 文本格式：$tke$1$算法$密钥ID$包装密钥$nonce$tag$密文，二进制字段为不带填充的 URL base64。
+// 本包旧版 API 输出的密文不是信封，需先通过 ImportLegacy 转换
+type Envelope struct {
+	Version    uint8
+	Algorithm  CipherAlgorithm
+	KeyID      string
+	WrappedKey []byte //信封加密时包装后的数据密钥，为空表示 KeyID 对应的密钥直接加密
+	Nonce      []byte //GCM 的 nonce 或 CBC 的 IV
+	Tag        []byte //GCM 认证标签
+	Payload    []byte
+}
+
+// KeyProvider 按密钥 ID 提供密钥，供 Decrypt 解密直接以该密钥加密的信封
+//
+// 带包装密钥的信封由 KeyManager 解开数据密钥，不经过 KeyProvider
+type KeyProvider interface {
+	Key(keyID string) ([]byte, error)
+}
+
+// StaticKeys 固定的密钥 ID 到密钥的映射
+type StaticKeys map[string][]byte
+
+// Key 按密钥 ID 查找密钥
+func (s StaticKeys) Key(keyID string) ([]byte, error) {
+	key, ok := s[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+	return key, nil
+}
+
+// EncryptEnvelope 使用指定算法和密钥加密并生成信封
+//
+// @Description: CBC 使用随机 IV；只有 AES-GCM 支持附加认证数据
+// @param alg 加密算法
+// @param keyID 密钥 ID，解密时用于查找密钥
+// @param key AES 密钥，16、24或32字节
+// @param plaintext 明文
+// @param aad 附加认证数据，可省略
+// @return *Envelope
+// @return error
+func EncryptEnvelope(alg CipherAlgorithm, keyID string, key, plaintext []byte, aad ...[]byte) (*Envelope, error) {
+	e := &Envelope{Version: EnvelopeVersion, Algorithm: alg, KeyID: keyID}
+	if err := e.seal(key, plaintext, joinAAD(aad)); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Decrypt 解密二进制或文本信封
+//
+// @Description: 根据信封中的算法和密钥 ID 选择解密方式。直接以密钥加密的信封(EncryptEnvelope 输出)
+// 由 KeyProvider 提供密钥；带包装密钥的信封(Seal 输出)由 KeyManager 解开数据密钥
+// @param envelope 二进制或文本信封
+// @param keys 密钥来源，KeyProvider(如 StaticKeys)或 KeyManager(如 *Keyring)，不能为空
+// @param aad 附加认证数据，须与加密时一致
+// @return []byte 明文
+// @return error
+func Decrypt(envelope []byte, keys any, aad ...[]byte) ([]byte, error) {
+	if keys == nil {
+		return nil, errors.New("encrypt: keys are required")
+	}
+	e, err := ParseEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	var key []byte
+	if len(e.WrappedKey) > 0 {
+		km, ok := keys.(KeyManager)
+		if !ok {
+			return nil, errors.New("encrypt: envelope has a wrapped key but keys is not a KeyManager")
+		}
+		key, err = km.UnwrapDataKey(context.Background(), e.KeyID, e.WrappedKey)
+	} else {
+		kp, ok := keys.(KeyProvider)
+		if !ok {
+			return nil, errors.New("encrypt: envelope has no wrapped key but keys is not a KeyProvider")
+		}
+		key, err = kp.Key(e.KeyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.open(key, joinAAD(aad))
+}
+
+// ParseEnvelope 解析二进制或文本信封
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	if bytes.HasPrefix(data, []byte(envelopeTextPrefix)) {
+		return parseEnvelopeText(string(data))
+	}
+	e := &Envelope{}
+	if err := e.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ImportLegacy 将本包旧版 API 输出的密文转换为信封
+//
+// @Description: 旧版密文不记录算法和密钥，需由调用方指明。AesGcm.Encrypt/EncryptString 的输出为
+// base64(nonce(12) | 密文 | tag(16))；AesCbc.AesCbcEncrypt 的输出不含 IV，需传入加密时配置的 IV；
+// AesEcb.AesEcbEncrypt 无 IV。旧版密文没有认证信封头部，因此先按旧格式解密，再用同一算法和密钥重新加密；
+// 转换后的信封可直接交给 Decrypt，也可序列化后替换旧数据完成迁移
+// @param alg 旧数据的算法，如 PKCS7 填充的 AesCbc 对应 AlgAesCbcPKCS7
+// @param keyID 解密时查找密钥使用的 ID
+// @param key 旧数据使用的 AES 密钥
+// @param ciphertext 旧版 API 输出的标准 base64 密文
+// @param iv CBC 加密时的 IV，其他算法传 nil
+// @return *Envelope
+// @return error
+func ImportLegacy(alg CipherAlgorithm, keyID string, key []byte, ciphertext string, iv []byte) (*Envelope, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, ErrMalformedCiphertext
+	}
+	legacy := &Envelope{Version: EnvelopeVersion, Algorithm: alg, KeyID: keyID}
+	if err := legacy.check(); err != nil {
+		return nil, err
+	}
+	var plaintext []byte
+	switch alg {
+	case AlgAesGcm:
+		plaintext, err = gcmOpen(key, data, nil)
+	case AlgAesCbcPKCS7, AlgAesCbcZero:
+		if len(iv) != BlockSize {
+			return nil, fmt.Errorf("encrypt: %v requires a %d byte iv", alg, BlockSize)
+		}
+		legacy.Nonce, legacy.Payload = iv, data
+		plaintext, err = legacy.open(key, nil)
+	default:
+		legacy.Payload = data
+		plaintext, err = legacy.open(key, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return EncryptEnvelope(alg, keyID, key, plaintext)
+}
+
+// MarshalBinary 序列化为二进制信封
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	if err := e.check(); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, 11+len(e.KeyID)+len(e.WrappedKey)+len(e.Nonce)+len(e.Tag)+len(e.Payload))
+	out = append(out, envelopeMagic...)
+	out = append(out, EnvelopeVersion, byte(e.Algorithm), byte(len(e.KeyID)))
+	out = append(out, e.KeyID...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(e.WrappedKey)))
+	out = append(out, e.WrappedKey...)
+	out = append(out, byte(len(e.Nonce)))
+	out = append(out, e.Nonce...)
+	out = append(out, byte(len(e.Tag)))
+	out = append(out, e.Tag...)
+	return append(out, e.Payload...), nil
+}
+
+// UnmarshalBinary 解析二进制信封
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(envelopeMagic)) {
+		return fmt.Errorf("%w: missing envelope header", ErrUnsupportedEnvelope)
+	}
+	r := envelopeReader{data: data[len(envelopeMagic):]}
+	if version := r.byte(); r.err == nil && version != EnvelopeVersion {
+		return fmt.Errorf("%w: version %d", ErrUnsupportedEnvelope, version)
+	}
+	*e = Envelope{Version: EnvelopeVersion, Algorithm: CipherAlgorithm(r.byte())}
+	e.KeyID = string(r.next(int(r.byte())))
+	e.WrappedKey = r.next(r.uint16())
+	e.Nonce = r.next(int(r.byte()))
+	e.Tag = r.next(int(r.byte()))
+	e.Payload = r.rest()
+	if r.err != nil {
+		return ErrMalformedCiphertext
+	}
+	return nil
+}
+
+// MarshalText 序列化为文本信封
+func (e *Envelope) MarshalText() ([]byte, error) {
+	if err := e.check(); err != nil {
+		return nil, err
+	}
+	fields := []string{
+		strconv.Itoa(EnvelopeVersion),
+		e.Algorithm.String(),
+		e.KeyID,
+		envelopeEncoding.EncodeToString(e.WrappedKey),
+		envelopeEncoding.EncodeToString(e.Nonce),
+		envelopeEncoding.EncodeToString(e.Tag),
+		envelopeEncoding.EncodeToString(e.Payload),
+	}
+	return []byte(envelopeTextPrefix + strings.Join(fields, "$")), nil
+}
+
+// UnmarshalText 解析文本信封
+func (e *Envelope) UnmarshalText(text []byte) error {
+	parsed, err := parseEnvelopeText(string(text))
+	if err != nil {
+		return err
+	}
+	*e = *parsed
+	return nil
+}
+
+// parseEnvelopeText 解析文本信封
+func parseEnvelopeText(s string) (*Envelope, error) {
+	fields := strings.Split(strings.TrimPrefix(s, envelopeTextPrefix), "$")
+	if len(fields) != 7 {
+		return nil, ErrMalformedCiphertext
+	}
+	if fields[0] != strconv.Itoa(EnvelopeVersion) {
+		return nil, fmt.Errorf("%w: version %s", ErrUnsupportedEnvelope, fields[0])
+	}
+	e := &Envelope{Version: EnvelopeVersion, KeyID: fields[2]}
+	for alg, name := range algorithmNames {
+		if name == fields[1] {
+			e.Algorithm = alg
+		}
+	}
+	if e.Algorithm == 0 {
+		return nil, fmt.Errorf("%w: algorithm %s", ErrUnsupportedEnvelope, fields[1])
+	}
+	for i, dst := range []*[]byte{&e.WrappedKey, &e.Nonce, &e.Tag, &e.Payload} {
+		b, err := envelopeEncoding.DecodeString(fields[3+i])
+		if err != nil {
+			return nil, ErrMalformedCiphertext
+		}
+		*dst = b
+	}
+	return e, nil
+}
+
+// check 校验字段长度
+func (e *Envelope) check() error {
+	if _, ok := algorithmNames[e.Algorithm]; !ok {
+		return fmt.Errorf("%w: %v", ErrUnsupportedEnvelope, e.Algorithm)
+	}
+	if len(e.KeyID) > 255 || strings.Contains(e.KeyID, "$") || len(e.WrappedKey) > 65535 || len(e.Nonce) > 255 || len(e.Tag) > 255 {
+		return errors.New("encrypt: envelope field too long or key id contains '$'")
+	}
+	return nil
+}
+
+// seal 按算法加密并填充 Nonce、Tag、Payload
+func (e *Envelope) seal(key, plaintext, aad []byte) error {
+	if err := e.check(); err != nil {
+		return err
+	}
+	if e.Algorithm == AlgAesGcm {
+		sealed, err := gcmSeal(key, plaintext, e.authData(aad))
+		if err != nil {
+			return err
+		}
+		//gcmSeal 输出 nonce(12) | 密文 | tag(16)
+		e.Nonce, e.Payload, e.Tag = sealed[:12], sealed[12:len(sealed)-16], sealed[len(sealed)-16:]
+		return nil
+	}
+	if len(aad) > 0 {
+		return fmt.Errorf("encrypt: %v does not support associated data", e.Algorithm)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	var mode cipher.BlockMode
+	switch e.Algorithm {
+	case AlgAesCbcPKCS7, AlgAesCbcZero:
+		e.Nonce = make([]byte, BlockSize)
+		if _, err := rand.Read(e.Nonce); err != nil {
+			return err
+		}
+		mode = cipher.NewCBCEncrypter(block, e.Nonce)
+	default:
+		mode = newECBEncrypter(block)
+	}
+	var data []byte
+	if e.Algorithm == AlgAesCbcPKCS7 || e.Algorithm == AlgAesEcbPKCS7 {
+		data = pKCS7Padding(append([]byte(nil), plaintext...), BlockSize)
+	} else {
+		data = zeroPadding(append([]byte(nil), plaintext...), BlockSize)
+	}
+	e.Payload = make([]byte, len(data))
+	mode.CryptBlocks(e.Payload, data)
+	return nil
+}
+
+// open 按算法解密
+func (e *Envelope) open(key, aad []byte) ([]byte, error) {
+	if e.Algorithm == AlgAesGcm {
+		data := make([]byte, 0, len(e.Nonce)+len(e.Payload)+len(e.Tag))
+		data = append(append(append(data, e.Nonce...), e.Payload...), e.Tag...)
+		return gcmOpen(key, data, e.authData(aad))
+	}
+	if len(aad) > 0 {
+		return nil, fmt.Errorf("encrypt: %v does not support associated data", e.Algorithm)
+	}
+	if len(e.Payload) == 0 || len(e.Payload)%BlockSize != 0 {
+		return nil, ErrMalformedCiphertext
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var mode cipher.BlockMode
+	switch e.Algorithm {
+	case AlgAesCbcPKCS7, AlgAesCbcZero:
+		if len(e.Nonce) != BlockSize {
+			return nil, ErrMalformedCiphertext
+		}
+		mode = cipher.NewCBCDecrypter(block, e.Nonce)
+	case AlgAesEcbPKCS7, AlgAesEcbZero:
+		mode = newECBDecrypter(block)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedEnvelope, e.Algorithm)
+	}
+	out := make([]byte, len(e.Payload))
+	mode.CryptBlocks(out, e.Payload)
+	if e.Algorithm == AlgAesCbcZero || e.Algorithm == AlgAesEcbZero {
+		return bytes.TrimRight(out, "\x00"), nil
+	}
+	//校验 PKCS7 填充，避免错误密钥时越界
+	n := int(out[len(out)-1])
+	if n == 0 || n > BlockSize || !bytes.Equal(out[len(out)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, errors.New("encrypt: invalid padding")
+	}
+	return out[:len(out)-n], nil
+}
+
+// authData GCM 的附加认证数据：信封头部后接调用方的附加认证数据
+//
+// 头部为魔数、版本、算法，直接以密钥加密时还包括密钥 ID，篡改这些字段会导致认证失败。
+// 带包装密钥时密钥 ID 与包装密钥已在包装时认证(密钥 ID 是包装的附加认证数据)，不计入头部，
+// 以便 Rewrap 更换它们而无需重新加密数据
+func (e *Envelope) authData(aad []byte) []byte {
+	out := make([]byte, 0, len(envelopeMagic)+4+len(e.KeyID)+len(aad))
+	out = append(out, envelopeMagic...)
+	out = append(out, EnvelopeVersion, byte(e.Algorithm))
+	if len(e.WrappedKey) == 0 {
+		out = append(out, 0, byte(len(e.KeyID)))
+		out = append(out, e.KeyID...)
+	} else {
+		out = append(out, 1)
+	}
+	return append(out, aad...)
+}
+
+// joinAAD 合并可选的附加认证数据参数，每段前都加长度，避免拼接产生歧义
+//
+// 单段也加长度，否则一段本身是长度前缀编码的数据会与拆开的多段认证结果相同
+func joinAAD(aad [][]byte) []byte {
+	if len(aad) == 0 {
+		return nil
+	}
+	var out []byte
+	for _, a := range aad {
+		out = binary.BigEndian.AppendUint32(out, uint32(len(a)))
+		out = append(out, a...)
+	}
+	return out
+}
+
+// envelopeReader 顺序读取二进制信封，越界后记录错误
+type envelopeReader struct {
+	data []byte
+	err  error
+}
+
+func (r *envelopeReader) next(n int) []byte {
+	if r.err != nil || n > len(r.data) {
+		r.err = ErrMalformedCiphertext
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *envelopeReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *envelopeReader) uint16() int {
+	if b := r.next(2); b != nil {
+		return int(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *envelopeReader) rest() []byte {
+	b := r.data
+	r.data = nil
+	return b
+}
@@ -0,0 +1,303 @@
+package encrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrKeyNotFound 找不到密钥 ID 对应的密钥
+	ErrKeyNotFound = errors.New("encrypt: key not found")
+	// ErrWrongMasterKey 主密钥无法解开密钥环
+	ErrWrongMasterKey = errors.New("encrypt: wrong master key")
+)
+
+// DataKey 数据密钥
+//
+// Plaintext 用于加密数据，用完即丢弃；Wrapped 是用 KeyID 对应的密钥加密后的数据密钥，与密文一起保存
+type DataKey struct {
+	KeyID     string
+	Plaintext []byte
+	Wrapped   []byte
+}
+
+// KeyManager 密钥管理
+//
+// 采用信封加密：每份数据使用独立的数据密钥加密，数据密钥再由 KeyManager 管理的密钥包装。
+// 轮换密钥时只需重新包装数据密钥，不必重新加密数据。可基于云厂商 KMS 实现
+type KeyManager interface {
+	// GenerateDataKey 使用当前主用密钥生成并包装一个 AES-256 数据密钥
+	GenerateDataKey(ctx context.Context) (*DataKey, error)
+	// UnwrapDataKey 使用 keyID 对应的密钥解开数据密钥
+	UnwrapDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// keyringFile 密钥环文件内容
+type keyringFile struct {
+	Version int           `json:"version"`
+	Primary string        `json:"primary"`
+	Keys    []keyringItem `json:"keys"`
+}
+
+// keyringItem 密钥环中的一个密钥，Key 为主密钥加密后的密钥
+type keyringItem struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Key       []byte    `json:"key"`
+}
+
+// Keyring 本地密钥环
+//
+// 密钥环文件保存若干带 ID 的密钥加密密钥，每个密钥都用主密钥以 AES-256-GCM 加密。
+// 主密钥不落盘，通常来自环境变量或密钥管理服务。密钥加密密钥只用于包装数据密钥，不对外提供，也不直接加密数据
+type Keyring struct {
+	mu      sync.RWMutex
+	path    string
+	master  []byte
+	keys    map[string][]byte
+	created map[string]time.Time
+	primary string
+}
+
+// OpenKeyring 打开本地密钥环，文件不存在时创建并生成第一个密钥
+//
+// @param path 密钥环文件路径
+// @param masterKey 32字节主密钥
+// @return *Keyring
+// @return error 主密钥错误时返回 ErrWrongMasterKey
+func OpenKeyring(path string, masterKey []byte) (*Keyring, error) {
+	if len(masterKey) != 32 {
+		return nil, errors.New("encrypt: master key must be 32 bytes")
+	}
+	k := &Keyring{
+		path:    path,
+		master:  append([]byte(nil), masterKey...),
+		keys:    make(map[string][]byte),
+		created: make(map[string]time.Time),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, fmt.Errorf("encrypt: create keyring dir: %w", err)
+		}
+		if _, err := k.Rotate(); err != nil {
+			return nil, err
+		}
+		return k, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: read keyring: %w", err)
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("encrypt: decode keyring: %w", err)
+	}
+	for _, item := range file.Keys {
+		key, err := gcmOpen(k.master, item.Key, []byte(item.ID))
+		if err != nil {
+			return nil, ErrWrongMasterKey
+		}
+		k.keys[item.ID] = key
+		k.created[item.ID] = item.CreatedAt
+	}
+	if _, ok := k.keys[file.Primary]; !ok {
+		return nil, fmt.Errorf("encrypt: keyring primary key %q missing", file.Primary)
+	}
+	k.primary = file.Primary
+	return k, nil
+}
+
+// PrimaryKeyID 当前主用密钥 ID
+func (k *Keyring) PrimaryKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// KeyIDs 全部密钥 ID，按创建时间排序
+func (k *Keyring) KeyIDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return k.created[ids[i]].Before(k.created[ids[j]]) })
+	return ids
+}
+
+// Rotate 生成新密钥并设为主用密钥
+//
+// @Description: 旧密钥保留用于解密，新数据密钥使用新密钥包装
+// @return string 新密钥 ID
+// @return error
+func (k *Keyring) Rotate() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = key
+	k.created[id] = time.Now()
+	prev := k.primary
+	k.primary = id
+	if err := k.save(k.master); err != nil {
+		delete(k.keys, id)
+		delete(k.created, id)
+		k.primary = prev
+		return "", err
+	}
+	return id, nil
+}
+
+// Remove 删除密钥，不能删除主用密钥；删除前应确保没有密文仍在使用该密钥
+func (k *Keyring) Remove(keyID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if keyID == k.primary {
+		return fmt.Errorf("encrypt: cannot remove primary key %q", keyID)
+	}
+	key, ok := k.keys[keyID]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	created := k.created[keyID]
+	delete(k.keys, keyID)
+	delete(k.created, keyID)
+	if err := k.save(k.master); err != nil {
+		k.keys[keyID], k.created[keyID] = key, created
+		return err
+	}
+	return nil
+}
+
+// ChangeMasterKey 更换主密钥并重写密钥环文件，密钥本身不变
+//
+// @param masterKey 新的32字节主密钥
+// @return error
+func (k *Keyring) ChangeMasterKey(masterKey []byte) error {
+	if len(masterKey) != 32 {
+		return errors.New("encrypt: master key must be 32 bytes")
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.save(masterKey); err != nil {
+		return err
+	}
+	k.master = append([]byte(nil), masterKey...)
+	return nil
+}
+
+// GenerateDataKey 生成数据密钥
+func (k *Keyring) GenerateDataKey(ctx context.Context) (*DataKey, error) {
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+	id, wrapped, err := k.WrapDataKey(ctx, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: id, Plaintext: plaintext, Wrapped: wrapped}, nil
+}
+
+// UnwrapDataKey 解开数据密钥
+func (k *Keyring) UnwrapDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	k.mu.RLock()
+	kek, ok := k.keys[keyID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+	return gcmOpen(kek, wrapped, []byte(keyID))
+}
+
+// save 用 master 加密全部密钥并写入文件，调用方需持有写锁
+func (k *Keyring) save(master []byte) error {
+	file := keyringFile{Version: 1, Primary: k.primary}
+	for id, key := range k.keys {
+		sealed, err := gcmSeal(master, key, []byte(id))
+		if err != nil {
+			return err
+		}
+		file.Keys = append(file.Keys, keyringItem{ID: id, CreatedAt: k.created[id], Key: sealed})
+	}
+	sort.Slice(file.Keys, func(i, j int) bool { return file.Keys[i].CreatedAt.Before(file.Keys[j].CreatedAt) })
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("encrypt: write keyring: %w", err)
+	}
+	return os.Rename(tmp, k.path)
+}
+
+// gcmSeal AES-GCM 加密，返回 nonce + 密文
+func gcmSeal(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// gcmOpen AES-GCM 解密 nonce + 密文
+func gcmOpen(key, data, aad []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], aad)
+}
+
+// newGCM 创建 AES-GCM，密钥长度须为16、24或32字节
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// WrapDataKey 使用当前主用密钥包装已有数据密钥
+func (k *Keyring) WrapDataKey(ctx context.Context, plaintext []byte) (string, []byte, error) {
+	k.mu.RLock()
+	id, kek := k.primary, k.keys[k.primary]
+	k.mu.RUnlock()
+	wrapped, err := gcmSeal(kek, plaintext, []byte(id))
+	if err != nil {
+		return "", nil, err
+	}
+	return id, wrapped, nil
+}
+
+var _ DataKeyWrapper = (*Keyring)(nil)
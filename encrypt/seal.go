@@ -0,0 +1,87 @@
+package encrypt
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMalformedCiphertext 密文格式错误
+var ErrMalformedCiphertext = errors.New("encrypt: malformed ciphertext")
+
+// Seal 信封加密
+//
+// @Description: 生成新的数据密钥以 AES-256-GCM 加密数据，输出二进制 Envelope，
+// 其中记录密钥 ID 和包装后的数据密钥
+// @param ctx 上下文
+// @param km 密钥管理
+// @param plaintext 明文
+// @param aad 附加认证数据，解密时须一致，可为空
+// @return []byte 二进制信封
+// @return error
+func Seal(ctx context.Context, km KeyManager, plaintext, aad []byte) ([]byte, error) {
+	dk, err := km.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e := &Envelope{Version: EnvelopeVersion, Algorithm: AlgAesGcm, KeyID: dk.KeyID, WrappedKey: dk.Wrapped}
+	if err := e.seal(dk.Plaintext, plaintext, aad); err != nil {
+		return nil, err
+	}
+	return e.MarshalBinary()
+}
+
+// Open 信封解密
+//
+// @param ctx 上下文
+// @param km 密钥管理
+// @param sealed Seal 输出的信封
+// @param aad 附加认证数据
+// @return []byte 明文
+// @return error
+func Open(ctx context.Context, km KeyManager, sealed, aad []byte) ([]byte, error) {
+	e, err := ParseEnvelope(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.WrappedKey) == 0 {
+		return nil, ErrMalformedCiphertext
+	}
+	key, err := km.UnwrapDataKey(ctx, e.KeyID, e.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return e.open(key, aad)
+}
+
+// Rewrap 用当前主用密钥重新包装密文中的数据密钥，数据部分保持不变
+//
+// @Description: 轮换密钥后可逐步 Rewrap 旧密文，完成后即可删除旧密钥
+// @param ctx 上下文
+// @param km 密钥管理，需实现 DataKeyWrapper
+// @param sealed Seal 输出的信封
+// @return []byte 新信封
+// @return error
+func Rewrap(ctx context.Context, km DataKeyWrapper, sealed []byte) ([]byte, error) {
+	e, err := ParseEnvelope(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.WrappedKey) == 0 {
+		return nil, ErrMalformedCiphertext
+	}
+	key, err := km.UnwrapDataKey(ctx, e.KeyID, e.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	if e.KeyID, e.WrappedKey, err = km.WrapDataKey(ctx, key); err != nil {
+		return nil, err
+	}
+	return e.MarshalBinary()
+}
+
+// DataKeyWrapper 可以包装已有数据密钥的密钥管理
+type DataKeyWrapper interface {
+	KeyManager
+	// WrapDataKey 使用当前主用密钥包装数据密钥
+	WrapDataKey(ctx context.Context, plaintext []byte) (keyID string, wrapped []byte, err error)
+}
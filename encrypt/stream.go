@@ -0,0 +1,284 @@
+package encrypt
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// DefaultStreamChunkSize 流式加密默认分块大小
+	DefaultStreamChunkSize = 64 << 10
+	// maxStreamChunkSize 允许的最大分块，防止伪造的头部导致大量分配
+	maxStreamChunkSize = 16 << 20
+
+	streamMagic       = "TKS1"
+	streamSaltSize    = 32
+	streamPrefixSize  = 7
+	streamHeaderSize  = len(streamMagic) + 4 + streamSaltSize + streamPrefixSize
+	streamKeyInfo     = "toolkit/encrypt stream v1"
+	streamTagSize     = 16
+	streamLastChunk   = 1
+	streamMiddleChunk = 0
+)
+
+var (
+	// ErrStreamTruncated 密文流在最后一块之前结束，数据被截断
+	ErrStreamTruncated = errors.New("encrypt: stream truncated")
+	// ErrStreamCorrupted 密文流的头部或分块认证失败
+	ErrStreamCorrupted = errors.New("encrypt: stream corrupted")
+)
+
+// streamNonce 分块 nonce：随机前缀(7) | 分块序号(4) | 最后一块标记(1)
+func streamNonce(prefix []byte, counter uint32, last byte) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	return append(nonce, last)
+}
+
+// streamAEAD 以 HKDF-SHA256 从密钥和头部中的随机盐派生本流的子密钥
+//
+// 每个流使用独立的子密钥，同一密钥加密大量流时不依赖 7 字节随机 nonce 前缀不碰撞
+func streamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	if _, err := newGCM(key); err != nil {
+		return nil, err
+	}
+	subkey, err := hkdf.Key(sha256.New, key, salt, streamKeyInfo, len(key))
+	if err != nil {
+		return nil, err
+	}
+	return newGCM(subkey)
+}
+
+// encryptWriter 流式加密写入器
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	buf     []byte
+	size    int
+	counter uint32
+	closed  bool
+	err     error
+}
+
+// NewEncryptWriter 创建流式加密写入器，使用默认分块大小
+//
+// @Description: 见 NewEncryptWriterSize
+// @param w 密文输出
+// @param key 16、24或32字节 AES 密钥
+// @return io.WriteCloser 写入明文，必须调用 Close 写入最后一块
+// @return error
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	return NewEncryptWriterSize(w, key, DefaultStreamChunkSize)
+}
+
+// NewEncryptWriterSize 创建指定分块大小的流式加密写入器
+//
+// @Description: 明文按 chunkSize 分块以 AES-GCM 加密，每个流以 HKDF-SHA256 从 key 和头部中的随机盐派生独立子密钥，
+// 每块 nonce 包含分块序号和最后一块标记，头部作为每块的附加认证数据，
+// 因此分块重排、删除、截断或篡改头部都会在解密时被发现。
+// 格式：魔数 "TKS1" | 分块大小(4) | 盐(32) | nonce 前缀(7) | 密文块...。
+// 适合加密 utils.Zip 生成的备份等大文件，内存占用只与分块大小有关
+// @param w 密文输出
+// @param key 16、24或32字节 AES 密钥
+// @param chunkSize 分块大小，1字节到16MB
+// @return io.WriteCloser 写入明文，必须调用 Close 写入最后一块
+// @return error
+func NewEncryptWriterSize(w io.Writer, key []byte, chunkSize int) (io.WriteCloser, error) {
+	if chunkSize <= 0 || chunkSize > maxStreamChunkSize {
+		return nil, fmt.Errorf("encrypt: chunk size must be between 1 and %d", maxStreamChunkSize)
+	}
+	random := make([]byte, streamSaltSize+streamPrefixSize)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	salt, prefix := random[:streamSaltSize], random[streamSaltSize:]
+	aead, err := streamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, streamHeaderSize)
+	header = append(header, streamMagic...)
+	header = binary.BigEndian.AppendUint32(header, uint32(chunkSize))
+	header = append(header, random...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		prefix: prefix,
+		buf:    make([]byte, 0, chunkSize+streamTagSize),
+		size:   chunkSize,
+	}, nil
+}
+
+// Write 写入明文，缓冲满一块且还有后续数据时才加密输出，保证最后一块由 Close 写出
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("encrypt: write to closed stream")
+	}
+	if e.err != nil {
+		return 0, e.err
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(e.buf) == e.size {
+			if err := e.flush(streamMiddleChunk); err != nil {
+				return n, err
+			}
+		}
+		m := min(len(p), e.size-len(e.buf))
+		e.buf = append(e.buf, p[:m]...)
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close 加密并写出最后一块，不会关闭底层 io.Writer
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	if e.err != nil {
+		return e.err
+	}
+	e.closed = true
+	return e.flush(streamLastChunk)
+}
+
+// flush 加密缓冲区并写出
+func (e *encryptWriter) flush(last byte) error {
+	if e.counter == math.MaxUint32 {
+		e.err = errors.New("encrypt: stream too long")
+		return e.err
+	}
+	out := e.aead.Seal(e.buf[:0], streamNonce(e.prefix, e.counter, last), e.buf, e.header)
+	if _, err := e.w.Write(out); err != nil {
+		e.err = err
+		return err
+	}
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// decryptReader 流式解密读取器
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	prefix  []byte
+	chunk   []byte
+	out     []byte
+	plain   []byte
+	counter uint32
+	done    bool
+	err     error
+}
+
+// NewDecryptReader 创建流式解密读取器
+//
+// @Description: 读取并校验头部，之后按块解密；只有读到认证通过的最后一块才会返回 io.EOF
+// @param r 密文输入
+// @param key 加密时使用的密钥
+// @return io.Reader 读取明文
+// @return error 头部格式错误
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	if _, err := newGCM(key); err != nil {
+		return nil, err
+	}
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrStreamCorrupted
+	}
+	if string(header[:len(streamMagic)]) != streamMagic {
+		return nil, ErrStreamCorrupted
+	}
+	size := int(binary.BigEndian.Uint32(header[len(streamMagic):]))
+	if size <= 0 || size > maxStreamChunkSize {
+		return nil, ErrStreamCorrupted
+	}
+	salt := header[len(streamMagic)+4 : len(streamMagic)+4+streamSaltSize]
+	aead, err := streamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      bufio.NewReader(r),
+		aead:   aead,
+		header: header,
+		prefix: header[len(streamMagic)+4+streamSaltSize:],
+		chunk:  make([]byte, size+streamTagSize),
+		out:    make([]byte, 0, size),
+	}, nil
+}
+
+// Read 读取明文
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.next()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next 读取并解密下一块
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.chunk)
+	switch {
+	case errors.Is(err, io.EOF):
+		return ErrStreamTruncated
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		//不足一整块，只能是最后一块
+		d.done = true
+	case err != nil:
+		return err
+	default:
+		//整块之后没有数据时为最后一块
+		if _, err := d.r.Peek(1); errors.Is(err, io.EOF) {
+			d.done = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if n < streamTagSize {
+		return ErrStreamTruncated
+	}
+	last := byte(streamMiddleChunk)
+	if d.done {
+		last = streamLastChunk
+	}
+	plain, err := d.aead.Open(d.out[:0], streamNonce(d.prefix, d.counter, last), d.chunk[:n], d.header)
+	if err != nil {
+		if d.done {
+			//最后一块标记不匹配，说明流在中间被截断
+			if _, e := d.aead.Open(nil, streamNonce(d.prefix, d.counter, streamMiddleChunk), d.chunk[:n], d.header); e == nil {
+				return ErrStreamTruncated
+			}
+		}
+		return ErrStreamCorrupted
+	}
+	d.counter++
+	d.plain = plain
+	return nil
+}
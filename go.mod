@@ -3,7 +3,6 @@ module github.com/xierui921326/toolkit
 go 1.24.0
 
 require (
-	github.com/bwmarrin/snowflake v0.3.0
 	github.com/iancoleman/strcase v0.3.0
 	golang.org/x/crypto v0.41.0
 )
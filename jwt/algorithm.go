@@ -0,0 +1,159 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"math/big"
+)
+
+// Algorithm 签名算法(JWS alg)
+type Algorithm string
+
+// 支持的签名算法
+const (
+	HS256 Algorithm = "HS256"
+	HS384 Algorithm = "HS384"
+	HS512 Algorithm = "HS512"
+	RS256 Algorithm = "RS256"
+	ES256 Algorithm = "ES256"
+	EdDSA Algorithm = "EdDSA"
+)
+
+// sign 使用密钥材料签名
+func (a Algorithm) sign(material any, input []byte) ([]byte, error) {
+	switch a {
+	case HS256, HS384, HS512:
+		secret, ok := material.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, ErrInvalidKey
+		}
+		mac := hmac.New(a.hash(), secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case RS256:
+		key, ok := material.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		sum := sha256.Sum256(input)
+		return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	case ES256:
+		key, ok := material.(*ecdsa.PrivateKey)
+		if !ok || key.Curve != elliptic.P256() {
+			return nil, ErrInvalidKey
+		}
+		sum := sha256.Sum256(input)
+		r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+		if err != nil {
+			return nil, err
+		}
+		//JWS 要求定长的 r||s，而不是 ASN.1 编码
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	case EdDSA:
+		key, ok := material.(ed25519.PrivateKey)
+		if !ok || len(key) != ed25519.PrivateKeySize {
+			return nil, ErrInvalidKey
+		}
+		return ed25519.Sign(key, input), nil
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// verify 使用密钥材料验签，私钥会自动取其公钥
+func (a Algorithm) verify(material any, input, sig []byte) error {
+	switch a {
+	case HS256, HS384, HS512:
+		expected, err := a.sign(material, input)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(expected, sig) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	case RS256:
+		var key *rsa.PublicKey
+		switch k := material.(type) {
+		case *rsa.PublicKey:
+			key = k
+		case *rsa.PrivateKey:
+			key = &k.PublicKey
+		default:
+			return ErrInvalidKey
+		}
+		sum := sha256.Sum256(input)
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig) != nil {
+			return ErrSignatureInvalid
+		}
+		return nil
+	case ES256:
+		var key *ecdsa.PublicKey
+		switch k := material.(type) {
+		case *ecdsa.PublicKey:
+			key = k
+		case *ecdsa.PrivateKey:
+			key = &k.PublicKey
+		default:
+			return ErrInvalidKey
+		}
+		if key.Curve != elliptic.P256() {
+			return ErrInvalidKey
+		}
+		if len(sig) != 64 {
+			return ErrSignatureInvalid
+		}
+		sum := sha256.Sum256(input)
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(key, sum[:], r, s) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	case EdDSA:
+		var key ed25519.PublicKey
+		switch k := material.(type) {
+		case ed25519.PublicKey:
+			key = k
+		case ed25519.PrivateKey:
+			key = k.Public().(ed25519.PublicKey)
+		default:
+			return ErrInvalidKey
+		}
+		if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, input, sig) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	}
+	return ErrUnsupportedAlgorithm
+}
+
+// hash HMAC 算法对应的哈希函数
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case HS384:
+		return sha512.New384
+	case HS512:
+		return sha512.New
+	default:
+		return sha256.New
+	}
+}
+
+// supported 是否为支持的算法
+func (a Algorithm) supported() bool {
+	switch a {
+	case HS256, HS384, HS512, RS256, ES256, EdDSA:
+		return true
+	}
+	return false
+}
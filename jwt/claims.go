@@ -0,0 +1,95 @@
+package jwt
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// NumericDate JWT 时间，序列化为 Unix 秒
+type NumericDate struct {
+	time.Time
+}
+
+// NewNumericDate 创建 JWT 时间，精度截断到秒
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{t.Truncate(time.Second)}
+}
+
+// MarshalJSON 序列化为 Unix 秒
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Unix())
+}
+
+// UnmarshalJSON 解析 Unix 秒，兼容小数
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	sec := int64(f)
+	d.Time = time.Unix(sec, int64((f-float64(sec))*1e9))
+	return nil
+}
+
+// Audience 接收方，单个时序列化为字符串，多个时序列化为数组
+type Audience []string
+
+// MarshalJSON 序列化接收方
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON 解析字符串或字符串数组
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// RegisteredClaims 标准声明(RFC 7519 4.1)，可嵌入自定义声明结构体
+type RegisteredClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// validate 按配置校验标准声明
+func (c *RegisteredClaims) validate(o *parseOptions) error {
+	now := o.now()
+	if c.ExpiresAt == nil {
+		if o.requireExp {
+			return ErrMissingExpiration
+		}
+	} else if !now.Before(c.ExpiresAt.Add(o.leeway)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != nil && now.Add(o.leeway).Before(c.NotBefore.Time) {
+		return ErrTokenNotYetValid
+	}
+	if c.IssuedAt != nil && now.Add(o.leeway).Before(c.IssuedAt.Time) {
+		return ErrIssuedInFuture
+	}
+	if o.issuer != "" && c.Issuer != o.issuer {
+		return ErrInvalidIssuer
+	}
+	if o.audience != "" && !slices.Contains(c.Audience, o.audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMalformed 令牌格式错误
+	ErrMalformed = errors.New("jwt: malformed token")
+	// ErrUnsupportedAlgorithm 不支持的签名算法(包括 none)
+	ErrUnsupportedAlgorithm = errors.New("jwt: unsupported algorithm")
+	// ErrInvalidKey 密钥类型与算法不匹配
+	ErrInvalidKey = errors.New("jwt: invalid key for algorithm")
+	// ErrKeyNotFound 找不到 kid 对应的密钥
+	ErrKeyNotFound = errors.New("jwt: key not found")
+	// ErrSignatureInvalid 签名无效
+	ErrSignatureInvalid = errors.New("jwt: signature is invalid")
+	// ErrTokenExpired 令牌已过期
+	ErrTokenExpired = errors.New("jwt: token is expired")
+	// ErrTokenNotYetValid 令牌尚未生效
+	ErrTokenNotYetValid = errors.New("jwt: token is not valid yet")
+	// ErrIssuedInFuture 签发时间晚于当前时间
+	ErrIssuedInFuture = errors.New("jwt: token used before issued")
+	// ErrInvalidIssuer 签发方不匹配
+	ErrInvalidIssuer = errors.New("jwt: invalid issuer")
+	// ErrInvalidAudience 接收方不匹配
+	ErrInvalidAudience = errors.New("jwt: invalid audience")
+	// ErrMissingExpiration 缺少过期时间
+	ErrMissingExpiration = errors.New("jwt: missing expiration")
+)
+
+// encoding JWS 使用不带填充的 URL base64
+var encoding = base64.RawURLEncoding
+
+// Header JOSE 头部
+type Header struct {
+	Algorithm Algorithm `json:"alg"`
+	Type      string    `json:"typ,omitempty"`
+	KeyID     string    `json:"kid,omitempty"`
+}
+
+// Sign 签名生成令牌
+//
+// @Description: 头部的 alg 与 kid 取自 key
+// @param claims 声明，任意可 JSON 序列化的值，通常嵌入 RegisteredClaims
+// @param key 签名密钥
+// @return string 令牌
+// @return error
+func Sign(claims any, key Key) (string, error) {
+	header, err := json.Marshal(Header{Algorithm: key.Algorithm, Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := encoding.EncodeToString(header) + "." + encoding.EncodeToString(payload)
+	sig, err := key.Algorithm.sign(key.Material, []byte(input))
+	if err != nil {
+		return "", err
+	}
+	return input + "." + encoding.EncodeToString(sig), nil
+}
+
+// parseOptions 解析与校验配置
+type parseOptions struct {
+	leeway     time.Duration
+	issuer     string
+	audience   string
+	requireExp bool
+	now        func() time.Time
+}
+
+// ParseOption 解析可选配置
+type ParseOption func(*parseOptions)
+
+// WithLeeway 设置校验时间类声明时允许的时钟偏差
+func WithLeeway(d time.Duration) ParseOption {
+	return func(o *parseOptions) {
+		o.leeway = d
+	}
+}
+
+// WithIssuer 要求 iss 等于 issuer
+func WithIssuer(issuer string) ParseOption {
+	return func(o *parseOptions) {
+		o.issuer = issuer
+	}
+}
+
+// WithAudience 要求 aud 包含 audience
+func WithAudience(audience string) ParseOption {
+	return func(o *parseOptions) {
+		o.audience = audience
+	}
+}
+
+// WithExpirationRequired 要求令牌必须带 exp
+func WithExpirationRequired() ParseOption {
+	return func(o *parseOptions) {
+		o.requireExp = true
+	}
+}
+
+// WithTimeFunc 设置当前时间函数，测试时可替换
+func WithTimeFunc(now func() time.Time) ParseOption {
+	return func(o *parseOptions) {
+		o.now = now
+	}
+}
+
+// Parse 验签并解析令牌
+//
+// @Description: 按头部 kid 查找密钥，并要求头部 alg 与密钥算法一致，防止算法混淆攻击；
+// 标准声明单独解析校验，因此 C 不必嵌入 RegisteredClaims
+// @param token 令牌
+// @param keys 密钥集合
+// @param opts 可选配置，如 WithIssuer、WithAudience
+// @return C 声明
+// @return error
+func Parse[C any](token string, keys *KeySet, opts ...ParseOption) (C, error) {
+	var claims C
+	o := &parseOptions{now: time.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrMalformed
+	}
+	var header Header
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims, err
+	}
+	if !header.Algorithm.supported() {
+		return claims, ErrUnsupportedAlgorithm
+	}
+	key, err := keys.lookup(header.KeyID)
+	if err != nil {
+		return claims, err
+	}
+	if key.Algorithm != header.Algorithm {
+		return claims, ErrInvalidKey
+	}
+	sig, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, ErrMalformed
+	}
+	if err := key.Algorithm.verify(key.Material, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return claims, err
+	}
+
+	var registered RegisteredClaims
+	if err := decodeSegment(parts[1], &registered); err != nil {
+		return claims, err
+	}
+	if err := registered.validate(o); err != nil {
+		return claims, err
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return claims, err
+	}
+	return claims, nil
+}
+
+// decodeSegment 解码 base64 片段并解析 JSON
+func decodeSegment(segment string, v any) error {
+	data, err := encoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
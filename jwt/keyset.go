@@ -0,0 +1,116 @@
+package jwt
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Key 带 ID 的密钥
+//
+// Material 为密钥材料：HS 系列为 []byte，RS256 为 *rsa.PrivateKey/*rsa.PublicKey，
+// ES256 为 P-256 的 *ecdsa.PrivateKey/*ecdsa.PublicKey，EdDSA 为 ed25519.PrivateKey/ed25519.PublicKey。
+// 只有私钥(或 HMAC 密钥)可以签名，公钥只能验签
+type Key struct {
+	ID        string
+	Algorithm Algorithm
+	Material  any
+}
+
+// KeySet 按 kid 索引的密钥集合，用于密钥轮换
+//
+// 签名使用主密钥并在头部写入 kid，验签时按 kid 查找密钥；
+// 轮换时先加入新密钥并设为主密钥，待旧令牌全部过期后再移除旧密钥
+type KeySet struct {
+	mu      sync.RWMutex
+	keys    map[string]Key
+	primary string
+}
+
+// NewKeySet 创建密钥集合
+//
+// @Description: 第一个密钥作为主密钥
+// @param keys 密钥
+// @return *KeySet
+// @return error kid 为空、重复或算法不支持
+func NewKeySet(keys ...Key) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]Key)}
+	for _, k := range keys {
+		if err := ks.Add(k); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// Add 加入密钥，集合为空时设为主密钥
+func (ks *KeySet) Add(key Key) error {
+	if key.ID == "" {
+		return fmt.Errorf("jwt: key id is required")
+	}
+	if !key.Algorithm.supported() {
+		return ErrUnsupportedAlgorithm
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.keys[key.ID]; ok {
+		return fmt.Errorf("jwt: duplicate key id %q", key.ID)
+	}
+	ks.keys[key.ID] = key
+	if ks.primary == "" {
+		ks.primary = key.ID
+	}
+	return nil
+}
+
+// SetPrimary 设置签名使用的主密钥
+func (ks *KeySet) SetPrimary(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, ok := ks.keys[kid]; !ok {
+		return ErrKeyNotFound
+	}
+	ks.primary = kid
+	return nil
+}
+
+// Remove 移除密钥，不能移除主密钥
+func (ks *KeySet) Remove(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if kid == ks.primary {
+		return fmt.Errorf("jwt: cannot remove primary key %q", kid)
+	}
+	delete(ks.keys, kid)
+	return nil
+}
+
+// Sign 使用主密钥签名
+//
+// @param claims 声明，任意可 JSON 序列化的值
+// @return string 令牌
+// @return error
+func (ks *KeySet) Sign(claims any) (string, error) {
+	ks.mu.RLock()
+	key, ok := ks.keys[ks.primary]
+	ks.mu.RUnlock()
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return Sign(claims, key)
+}
+
+// lookup 按 kid 查找密钥；令牌未带 kid 且集合只有一个密钥时使用该密钥
+func (ks *KeySet) lookup(kid string) (Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, nil
+		}
+	}
+	key, ok := ks.keys[kid]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	return key, nil
+}
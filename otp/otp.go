@@ -0,0 +1,316 @@
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+// Algorithm HMAC 算法
+type Algorithm string
+
+const (
+	// SHA1 HMAC-SHA1，RFC 4226 默认算法，各类验证器 App 均支持
+	SHA1 Algorithm = "SHA1"
+	// SHA256 HMAC-SHA256
+	SHA256 Algorithm = "SHA256"
+)
+
+var (
+	// ErrInvalidSecret 密钥不是合法的 base32 字符串
+	ErrInvalidSecret = errors.New("otp: invalid base32 secret")
+	// ErrInvalidOption 位数、周期或算法不合法
+	ErrInvalidOption = errors.New("otp: invalid option")
+)
+
+// base32NoPadding 不带填充的 base32 编码，验证器 App 通用格式
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// config 一次性密码配置
+type config struct {
+	digits    int
+	period    time.Duration
+	skew      int
+	algorithm Algorithm
+}
+
+// Option 可选配置
+type Option func(*config)
+
+// WithDigits 设置密码位数，6到8位，默认6位
+func WithDigits(n int) Option {
+	return func(c *config) {
+		c.digits = n
+	}
+}
+
+// WithPeriod 设置 TOTP 时间步长，默认30秒
+func WithPeriod(d time.Duration) Option {
+	return func(c *config) {
+		c.period = d
+	}
+}
+
+// WithSkew 设置校验时允许的漂移窗口
+//
+// @Description: TOTP 前后各容忍 n 个时间步，HOTP 向后查找 n 个计数器；默认1
+// @param n 窗口大小
+func WithSkew(n int) Option {
+	return func(c *config) {
+		c.skew = n
+	}
+}
+
+// WithAlgorithm 设置 HMAC 算法，默认 SHA1
+func WithAlgorithm(a Algorithm) Option {
+	return func(c *config) {
+		c.algorithm = a
+	}
+}
+
+// newConfig 应用配置并校验
+func newConfig(opts []Option) (*config, error) {
+	c := &config{digits: 6, period: 30 * time.Second, skew: 1, algorithm: SHA1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.digits < 6 || c.digits > 8 || c.period < time.Second || c.skew < 0 {
+		return nil, ErrInvalidOption
+	}
+	if c.algorithm != SHA1 && c.algorithm != SHA256 {
+		return nil, ErrInvalidOption
+	}
+	return c, nil
+}
+
+// GenerateSecret 生成随机 base32 密钥
+//
+// @Description: 使用 crypto/rand 生成，结果不带填充，可直接用于 otpauth URI
+// @param size 字节数，小于等于0时默认20字节(160位，RFC 4226 推荐长度)
+// @return string base32 密钥
+// @return error
+func GenerateSecret(size int) (string, error) {
+	if size <= 0 {
+		size = 20
+	}
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(b), nil
+}
+
+// decodeSecret 解码 base32 密钥，忽略大小写、空格和填充
+func decodeSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := base32NoPadding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
+
+// code 按 RFC 4226 计算计数器对应的密码
+func (c *config) code(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	var sum []byte
+	switch c.algorithm {
+	case SHA256:
+		sum = utils.HmacSha256(string(key), string(msg[:]))
+	default:
+		sum = utils.HmacSha1(string(key), string(msg[:]))
+	}
+	//动态截断
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < c.digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", c.digits, value%mod)
+}
+
+// HOTP 计算基于计数器的一次性密码(RFC 4226)
+//
+// @param secret base32 密钥
+// @param counter 计数器
+// @param opts 可选配置，如 WithDigits、WithAlgorithm
+// @return string 一次性密码
+// @return error
+func HOTP(secret string, counter uint64, opts ...Option) (string, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return "", err
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return c.code(key, counter), nil
+}
+
+// ValidateHOTP 校验基于计数器的一次性密码
+//
+// @Description: 从 counter 开始向后查找 skew 个计数器，校验通过后调用方应把计数器保存为返回值
+// @param code 用户输入的密码
+// @param secret base32 密钥
+// @param counter 服务端保存的计数器
+// @param opts 可选配置
+// @return uint64 下一次校验应使用的计数器
+// @return bool 是否通过
+// @return error
+func ValidateHOTP(code, secret string, counter uint64, opts ...Option) (uint64, bool, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return counter, false, err
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return counter, false, err
+	}
+	for i := 0; i <= c.skew; i++ {
+		if equal(c.code(key, counter+uint64(i)), code) {
+			return counter + uint64(i) + 1, true, nil
+		}
+	}
+	return counter, false, nil
+}
+
+// TOTP 计算基于时间的一次性密码(RFC 6238)
+//
+// @param secret base32 密钥
+// @param t 时间
+// @param opts 可选配置，如 WithPeriod、WithDigits
+// @return string 一次性密码
+// @return error
+func TOTP(secret string, t time.Time, opts ...Option) (string, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return "", err
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return c.code(key, c.step(t)), nil
+}
+
+// ValidateTOTP 校验基于时间的一次性密码
+//
+// @Description: 前后各容忍 skew 个时间步
+// @param code 用户输入的密码
+// @param secret base32 密钥
+// @param t 校验时间，通常为 time.Now()
+// @param opts 可选配置
+// @return bool 是否通过
+// @return error
+func ValidateTOTP(code, secret string, t time.Time, opts ...Option) (bool, error) {
+	_, ok, err := MatchTOTP(code, secret, t, opts...)
+	return ok, err
+}
+
+// MatchTOTP 校验基于时间的一次性密码并返回匹配的时间步
+//
+// @Description: 调用方可保存最近一次成功的时间步，拒绝小于等于它的时间步以防密码被重复使用
+// @param code 用户输入的密码
+// @param secret base32 密钥
+// @param t 校验时间
+// @param opts 可选配置
+// @return uint64 匹配的时间步
+// @return bool 是否通过
+// @return error
+func MatchTOTP(code, secret string, t time.Time, opts ...Option) (uint64, bool, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return 0, false, err
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	step := c.step(t)
+	for i := -c.skew; i <= c.skew; i++ {
+		if i < 0 && step < uint64(-i) {
+			continue
+		}
+		s := step + uint64(i)
+		if equal(c.code(key, s), code) {
+			return s, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// step 时间对应的时间步
+func (c *config) step(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(c.period/time.Second)
+}
+
+// equal 常数时间比较密码
+func equal(expected, code string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.TrimSpace(code))) == 1
+}
+
+// URI 生成 otpauth:// URI，可生成二维码供验证器 App 扫描
+//
+// @Description: 生成 TOTP 的 URI，格式见 Key Uri Format
+// @param issuer 签发方，如系统名称
+// @param account 账号，如邮箱
+// @param secret base32 密钥
+// @param opts 可选配置，需与校验时一致
+// @return string otpauth URI
+// @return error
+func URI(issuer, account, secret string, opts ...Option) (string, error) {
+	return buildURI("totp", issuer, account, secret, 0, opts)
+}
+
+// HOTPURI 生成 HOTP 的 otpauth:// URI
+//
+// @param issuer 签发方
+// @param account 账号
+// @param secret base32 密钥
+// @param counter 初始计数器
+// @param opts 可选配置
+// @return string otpauth URI
+// @return error
+func HOTPURI(issuer, account, secret string, counter uint64, opts ...Option) (string, error) {
+	return buildURI("hotp", issuer, account, secret, counter, opts)
+}
+
+// buildURI 生成 otpauth URI
+func buildURI(kind, issuer, account, secret string, counter uint64, opts []Option) (string, error) {
+	c, err := newConfig(opts)
+	if err != nil {
+		return "", err
+	}
+	if _, err := decodeSecret(secret); err != nil {
+		return "", err
+	}
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+	q := url.Values{}
+	q.Set("secret", strings.ToUpper(strings.TrimRight(secret, "=")))
+	if issuer != "" {
+		q.Set("issuer", issuer)
+	}
+	q.Set("algorithm", string(c.algorithm))
+	q.Set("digits", strconv.Itoa(c.digits))
+	if kind == "hotp" {
+		q.Set("counter", strconv.FormatUint(counter, 10))
+	} else {
+		q.Set("period", strconv.Itoa(int(c.period/time.Second)))
+	}
+	return "otpauth://" + kind + "/" + label + "?" + strings.ReplaceAll(q.Encode(), "+", "%20"), nil
+}
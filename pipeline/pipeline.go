@@ -0,0 +1,438 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/xierui921326/toolkit/queue"
+	"github.com/xierui921326/toolkit/worker_pool"
+)
+
+const (
+	defaultWorkers = 1
+	defaultBuffer  = 16
+)
+
+// Pipeline 流水线，管理所有阶段的生命周期与错误
+//
+// 每个阶段运行在独立的 worker_pool.WorkerPool 上，阶段之间通过有界 queue.Queue 连接。
+// 任一阶段返回错误时取消整个流水线，Wait 返回第一个错误
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	err    error
+	done   bool //Wait 已确定结果，之后的 Wait 直接返回 err
+}
+
+// New 创建流水线
+//
+// @Description: 创建流水线，ctx 取消时所有阶段停止
+// @param ctx 上下文
+// @return *Pipeline
+func New(ctx context.Context) *Pipeline {
+	p := &Pipeline{}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	return p
+}
+
+// Context 流水线上下文，出错或取消后结束
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Cancel 取消流水线
+func (p *Pipeline) Cancel() {
+	p.cancel()
+}
+
+// fail 记录第一个错误并取消流水线
+func (p *Pipeline) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+	p.cancel()
+}
+
+// Wait 等待所有阶段结束
+//
+// @Description: 等待所有阶段结束，返回第一个阶段错误；没有阶段错误但流水线被取消(父 ctx 结束或调用 Cancel)时返回 ctx.Err()。
+// 结果在第一次 Wait 时确定，之后重复调用返回相同结果
+// @return error
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.done {
+		p.done = true
+		if p.err == nil {
+			p.err = p.ctx.Err()
+		}
+		p.cancel()
+	}
+	return p.err
+}
+
+// item 流水线中传递的元素，seq 为所在流中的序号
+type item[T any] struct {
+	seq uint64
+	val T
+}
+
+// Stream 阶段输出流
+type Stream[T any] struct {
+	p *Pipeline
+	q *queue.Queue[item[T]]
+}
+
+func newStream[T any](p *Pipeline, buffer int) *Stream[T] {
+	return &Stream[T]{p: p, q: queue.NewBoundedQueue[item[T]](buffer)}
+}
+
+// StageOption 阶段可选配置
+type StageOption func(c *stageConfig)
+
+// stageConfig 阶段配置
+type stageConfig struct {
+	workers int
+	buffer  int
+	ordered bool
+}
+
+func newStageConfig(opts []StageOption) stageConfig {
+	c := stageConfig{workers: defaultWorkers, buffer: defaultBuffer}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithWorkers 设置阶段并发数，默认1
+//
+// @Description: 设置阶段线程池的 worker 数量
+// @param n 并发数
+// @return StageOption
+func WithWorkers(n int) StageOption {
+	return func(c *stageConfig) {
+		if n > 0 {
+			c.workers = n
+		}
+	}
+}
+
+// WithBuffer 设置阶段输出队列容量，默认16
+//
+// @Description: 设置阶段输出队列容量，下游处理不过来时上游阻塞；WithOrdered 时同时作为重排窗口大小
+// @param n 队列容量
+// @return StageOption
+func WithBuffer(n int) StageOption {
+	return func(c *stageConfig) {
+		if n > 0 {
+			c.buffer = n
+		}
+	}
+}
+
+// WithOrdered 保持输入顺序输出
+//
+// @Description: 并发处理时仍按输入顺序输出结果，领先最早未完成元素达到 WithBuffer 个的结果阻塞等待
+// @return StageOption
+func WithOrdered() StageOption {
+	return func(c *stageConfig) {
+		c.ordered = true
+	}
+}
+
+// Source 从切片创建输入流
+//
+// @Description: 从切片创建输入流
+// @param p 流水线
+// @param items 输入数据
+// @param opts 可选配置，仅 WithBuffer 生效
+// @return *Stream[T]
+func Source[T any](p *Pipeline, items []T, opts ...StageOption) *Stream[T] {
+	return Generate(p, func(ctx context.Context, emit func(T) error) error {
+		for _, v := range items {
+			if err := emit(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+// Generate 由生成函数创建输入流
+//
+// @Description: gen 通过 emit 逐个输出元素，emit 返回错误时 gen 应尽快返回；gen 返回错误时流水线失败
+// @param p 流水线
+// @param gen 生成函数
+// @param opts 可选配置，仅 WithBuffer 生效
+// @return *Stream[T]
+func Generate[T any](p *Pipeline, gen func(ctx context.Context, emit func(T) error) error, opts ...StageOption) *Stream[T] {
+	cfg := newStageConfig(opts)
+	out := newStream[T](p, cfg.buffer)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer out.q.Close()
+		var seq uint64
+		err := gen(p.ctx, func(v T) error {
+			if err := out.q.Put(p.ctx, item[T]{seq: seq, val: v}); err != nil {
+				return err
+			}
+			seq++
+			return nil
+		})
+		if err != nil && p.ctx.Err() == nil {
+			p.fail(err)
+		}
+	}()
+	return out
+}
+
+// Map 一对一转换阶段
+//
+// @Description: 在独立线程池上并发执行 fn
+// @param in 输入流
+// @param fn 转换函数
+// @param opts 可选配置，如 WithWorkers、WithBuffer、WithOrdered
+// @return *Stream[O]
+func Map[I, O any](in *Stream[I], fn func(ctx context.Context, v I) (O, error), opts ...StageOption) *Stream[O] {
+	return stage(in, func(ctx context.Context, v I) ([]O, error) {
+		o, err := fn(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		return []O{o}, nil
+	}, opts)
+}
+
+// FlatMap 一对多转换阶段(扇出)
+//
+// @Description: 在独立线程池上并发执行 fn，每个输入可输出任意个元素
+// @param in 输入流
+// @param fn 转换函数
+// @param opts 可选配置
+// @return *Stream[O]
+func FlatMap[I, O any](in *Stream[I], fn func(ctx context.Context, v I) ([]O, error), opts ...StageOption) *Stream[O] {
+	return stage(in, fn, opts)
+}
+
+// Filter 过滤阶段
+//
+// @Description: 在独立线程池上并发执行 fn，仅保留 fn 返回 true 的元素
+// @param in 输入流
+// @param fn 过滤函数
+// @param opts 可选配置
+// @return *Stream[T]
+func Filter[T any](in *Stream[T], fn func(ctx context.Context, v T) (bool, error), opts ...StageOption) *Stream[T] {
+	return stage(in, func(ctx context.Context, v T) ([]T, error) {
+		keep, err := fn(ctx, v)
+		if err != nil || !keep {
+			return nil, err
+		}
+		return []T{v}, nil
+	}, opts)
+}
+
+// Merge 合并多个流(扇入)
+//
+// @Description: 按到达顺序合并多个同一流水线的流
+// @param streams 输入流
+// @return *Stream[T]
+func Merge[T any](streams ...*Stream[T]) *Stream[T] {
+	if len(streams) == 0 {
+		panic("pipeline: Merge requires at least one stream")
+	}
+	p := streams[0].p
+	buffer := 0
+	for _, s := range streams {
+		buffer += s.q.Cap()
+	}
+	out := newStream[T](p, buffer)
+	var mu sync.Mutex
+	var seq uint64
+	var inputs sync.WaitGroup
+	for _, s := range streams {
+		inputs.Add(1)
+		p.wg.Add(1)
+		go func(s *Stream[T]) {
+			defer p.wg.Done()
+			defer inputs.Done()
+			for {
+				it, err := s.q.Take(p.ctx)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				it.seq = seq
+				seq++
+				err = out.q.Put(p.ctx, it)
+				mu.Unlock()
+				if err != nil {
+					return
+				}
+			}
+		}(s)
+	}
+	go func() {
+		inputs.Wait()
+		out.q.Close()
+	}()
+	return out
+}
+
+// Collect 读取流中的全部元素并等待流水线结束
+//
+// @Description: 读取流中的全部元素并等待流水线结束
+// @param s 输出流
+// @return []T 全部元素
+// @return error 流水线的第一个错误
+func Collect[T any](s *Stream[T]) ([]T, error) {
+	var result []T
+	for {
+		it, err := s.q.Take(s.p.ctx)
+		if err != nil {
+			break
+		}
+		result = append(result, it.val)
+	}
+	if err := s.p.Wait(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ForEach 对流中每个元素执行 fn 并等待流水线结束
+//
+// @Description: 在独立线程池上并发执行 fn
+// @param s 输入流
+// @param fn 处理函数
+// @param opts 可选配置
+// @return error 流水线的第一个错误
+func ForEach[T any](s *Stream[T], fn func(ctx context.Context, v T) error, opts ...StageOption) error {
+	sink := stage(s, func(ctx context.Context, v T) ([]struct{}, error) {
+		return nil, fn(ctx, v)
+	}, opts)
+	_, err := Collect(sink)
+	return err
+}
+
+// stage 在独立线程池上运行转换函数，连接输入流与输出流
+func stage[I, O any](in *Stream[I], fn func(ctx context.Context, v I) ([]O, error), opts []StageOption) *Stream[O] {
+	cfg := newStageConfig(opts)
+	p := in.p
+	out := newStream[O](p, cfg.buffer)
+	em := newEmitter(out, cfg.ordered, cfg.buffer)
+	context.AfterFunc(p.ctx, em.wake)
+	pool := worker_pool.NewWorkerPool(cfg.workers, worker_pool.WithQueueSize(cfg.workers))
+	pool.Run()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		var jobs sync.WaitGroup
+		defer func() {
+			jobs.Wait()
+			pool.Stop()
+			out.q.Close()
+		}()
+		for {
+			it, err := in.q.Take(p.ctx)
+			if err != nil {
+				return
+			}
+			jobs.Add(1)
+			job := worker_pool.JobFunc(func(context.Context) (err error) {
+				defer jobs.Done()
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("pipeline: stage panic: %v", r)
+						p.fail(err)
+					}
+				}()
+				vals, err := fn(p.ctx, it.val)
+				if err != nil {
+					p.fail(err)
+					return err
+				}
+				return em.emit(p.ctx, it.seq, vals)
+			})
+			if err := pool.AddContext(p.ctx, job); err != nil {
+				jobs.Done()
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// emitter 阶段输出，ordered 时按输入序号重排
+type emitter[T any] struct {
+	out     *Stream[T]
+	ordered bool
+	window  uint64 //重排窗口，序号领先 next 达到 window 的结果等待
+	mu      sync.Mutex
+	cond    *sync.Cond
+	next    uint64 //下一个待输出的输入序号
+	outSeq  uint64 //下一个输出序号
+	pending map[uint64][]T
+}
+
+func newEmitter[T any](out *Stream[T], ordered bool, window int) *emitter[T] {
+	e := &emitter[T]{out: out, ordered: ordered, window: uint64(window), pending: make(map[uint64][]T)}
+	e.cond = sync.NewCond(&e.mu)
+	return e
+}
+
+// wake 唤醒等待重排窗口的 emit，用于上下文取消
+func (e *emitter[T]) wake() {
+	e.mu.Lock()
+	e.cond.Broadcast()
+	e.mu.Unlock()
+}
+
+// emit 输出输入序号 seq 对应的结果
+//
+// ordered 时结果超出重排窗口则阻塞，占用的 worker 使阶段停止读取输入，pending 不会无限增长
+func (e *emitter[T]) emit(ctx context.Context, seq uint64, vals []T) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.ordered {
+		return e.put(ctx, vals)
+	}
+	for seq-e.next >= e.window {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		e.cond.Wait()
+	}
+	e.pending[seq] = vals
+	for {
+		vs, ok := e.pending[e.next]
+		if !ok {
+			return nil
+		}
+		delete(e.pending, e.next)
+		e.next++
+		e.cond.Broadcast()
+		if err := e.put(ctx, vs); err != nil {
+			return err
+		}
+	}
+}
+
+// put 写入输出队列，调用方需持有锁
+func (e *emitter[T]) put(ctx context.Context, vals []T) error {
+	for _, v := range vals {
+		if err := e.out.q.Put(ctx, item[T]{seq: e.outSeq, val: v}); err != nil {
+			return err
+		}
+		e.outSeq++
+	}
+	return nil
+}
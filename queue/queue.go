@@ -1,10 +1,19 @@
 package queue
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueClosed 队列已关闭
+var ErrQueueClosed = errors.New("queue: closed")
 
 type Queue[T any] struct {
-	items []T
-	cond  *sync.Cond
+	items    []T
+	cond     *sync.Cond
+	capacity int  //队列容量 0表示不限制
+	closed   bool //是否已关闭
 }
 
 // GetDefaultQueue 创建一个空队列
@@ -15,6 +24,19 @@ func GetDefaultQueue[T any]() *Queue[T] {
 	}
 }
 
+// NewBoundedQueue 创建一个有界队列
+//
+// @Description: 队列已满时 Enqueue、Put 阻塞，直到有元素出队
+// @param capacity 队列容量，小于等于0表示不限制
+// @return *Queue[T]
+func NewBoundedQueue[T any](capacity int) *Queue[T] {
+	q := GetDefaultQueue[T]()
+	if capacity > 0 {
+		q.capacity = capacity
+	}
+	return q
+}
+
 // Len 队列长度
 func (q *Queue[T]) Len() int {
 	q.cond.L.Lock()
@@ -22,14 +44,65 @@ func (q *Queue[T]) Len() int {
 	return len(q.items)
 }
 
+// Cap 队列容量，0表示不限制
+func (q *Queue[T]) Cap() int {
+	return q.capacity
+}
+
+// full 队列是否已满，调用方需持有锁
+func (q *Queue[T]) full() bool {
+	return q.capacity > 0 && len(q.items) >= q.capacity
+}
+
 // Enqueue 入队
+// 有界队列已满时阻塞，队列关闭后元素会被丢弃
 func (q *Queue[T]) Enqueue(item T) {
 	q.cond.L.Lock()
 	defer q.cond.L.Unlock()
+	for q.full() && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return
+	}
 	q.items = append(q.items, item)
 	q.cond.Broadcast()
 }
 
+// TryEnqueue 入队（非阻塞）
+// 返回是否入队成功，队列已满或已关闭时返回 false
+func (q *Queue[T]) TryEnqueue(item T) bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	if q.full() || q.closed {
+		return false
+	}
+	q.items = append(q.items, item)
+	q.cond.Broadcast()
+	return true
+}
+
+// Put 入队（阻塞，直到有空位、ctx 结束或队列关闭）
+func (q *Queue[T]) Put(ctx context.Context, item T) error {
+	stop := context.AfterFunc(ctx, q.wakeAll)
+	defer stop()
+
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for q.full() && !q.closed && ctx.Err() == nil {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return ErrQueueClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.items = append(q.items, item)
+	q.cond.Broadcast()
+	return nil
+}
+
 // Dequeue 出队（非阻塞）
 // 返回值 + 是否存在
 func (q *Queue[T]) Dequeue() (T, bool) {
@@ -39,9 +112,7 @@ func (q *Queue[T]) Dequeue() (T, bool) {
 		var zero T
 		return zero, false
 	}
-	item := q.items[0]
-	q.items = q.items[1:]
-	return item, true
+	return q.pop(), true
 }
 
 // BlockingDequeue 出队（阻塞，直到有值）
@@ -51,8 +122,36 @@ func (q *Queue[T]) BlockingDequeue() T {
 	for len(q.items) == 0 {
 		q.cond.Wait()
 	}
+	return q.pop()
+}
+
+// Take 出队（阻塞，直到有值、ctx 结束或队列关闭且已取空）
+func (q *Queue[T]) Take(ctx context.Context) (T, error) {
+	stop := context.AfterFunc(ctx, q.wakeAll)
+	defer stop()
+
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for len(q.items) == 0 && !q.closed && ctx.Err() == nil {
+		q.cond.Wait()
+	}
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	if len(q.items) == 0 {
+		return zero, ErrQueueClosed
+	}
+	return q.pop(), nil
+}
+
+// pop 取出队首元素并唤醒等待入队的协程，调用方需持有锁
+func (q *Queue[T]) pop() T {
+	var zero T
 	item := q.items[0]
+	q.items[0] = zero
 	q.items = q.items[1:]
+	q.cond.Broadcast()
 	return item
 }
 
@@ -65,6 +164,7 @@ func (q *Queue[T]) DequeueN(n int) []T {
 	}
 	result := q.items[:n]
 	q.items = q.items[n:]
+	q.cond.Broadcast()
 	return result
 }
 
@@ -74,6 +174,7 @@ func (q *Queue[T]) DequeueAll() []T {
 	defer q.cond.L.Unlock()
 	result := q.items
 	q.items = []T{}
+	q.cond.Broadcast()
 	return result
 }
 
@@ -82,4 +183,28 @@ func (q *Queue[T]) Reset() {
 	q.cond.L.Lock()
 	defer q.cond.L.Unlock()
 	q.items = []T{}
+	q.cond.Broadcast()
+}
+
+// Close 关闭队列
+// 关闭后不能再入队，已有元素仍可取出，阻塞中的 Put、Take 会被唤醒
+func (q *Queue[T]) Close() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// Closed 队列是否已关闭
+func (q *Queue[T]) Closed() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.closed
+}
+
+// wakeAll 唤醒所有等待的协程
+func (q *Queue[T]) wakeAll() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.cond.Broadcast()
 }
@@ -0,0 +1,333 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 调度规则
+type Schedule interface {
+	// Next 返回 t 之后的下一次执行时间，返回零值表示不再执行
+	Next(t time.Time) time.Time
+}
+
+// bounds 字段取值范围
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondBounds = bounds{min: 0, max: 59}
+	minuteBounds = bounds{min: 0, max: 59}
+	hourBounds   = bounds{min: 0, max: 23}
+	domBounds    = bounds{min: 1, max: 31}
+	monthBounds  = bounds{min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors 预定义的 cron 表达式
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule cron 表达式调度规则，每个字段用位图表示
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool //日、周字段是否为 * 或 ?
+}
+
+// Cron 解析 cron 表达式
+//
+// @Description: 支持5段(分 时 日 月 周)或6段(秒 分 时 日 月 周)表达式，
+// 支持 * ? , - / 语法、月份与星期英文缩写，以及 @yearly @monthly @weekly @daily @hourly @every <duration>
+// @param spec cron 表达式
+// @return Schedule 调度规则
+// @return error 表达式错误
+func Cron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("scheduler: invalid @every duration: %w", err)
+		}
+		if d <= 0 {
+			return nil, errors.New("scheduler: @every duration must be positive")
+		}
+		return Every(d), nil
+	}
+	if expr, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("scheduler: expected 5 or 6 fields, got %d in %q", len(fields), spec)
+	}
+
+	var (
+		c   cronSchedule
+		err error
+	)
+	if c.second, err = parseField(fields[0], secondBounds); err != nil {
+		return nil, err
+	}
+	if c.minute, err = parseField(fields[1], minuteBounds); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[2], hourBounds); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[3], domBounds); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[4], monthBounds); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[5], dowBounds); err != nil {
+		return nil, err
+	}
+	// 周日可以写作 0 或 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = fields[3] == "*" || fields[3] == "?"
+	c.dowStar = fields[5] == "*" || fields[5] == "?"
+	return &c, nil
+}
+
+// MustCron 解析 cron 表达式，出错时 panic
+//
+// @Description: 用于表达式为常量的场景
+// @param spec cron 表达式
+// @return Schedule 调度规则
+func MustCron(spec string) Schedule {
+	s, err := Cron(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// parseField 解析单个字段为位图
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		r, err := parseRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= r
+	}
+	return bits, nil
+}
+
+// parseRange 解析 *、a、a-b、*/n、a/n、a-b/n
+func parseRange(expr string, b bounds) (uint64, error) {
+	rangeAndStep := strings.Split(expr, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, fmt.Errorf("scheduler: invalid step in %q", expr)
+	}
+	var start, end uint
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	switch {
+	case lowAndHigh[0] == "*" || lowAndHigh[0] == "?":
+		if len(lowAndHigh) > 1 {
+			return 0, fmt.Errorf("scheduler: invalid range %q", expr)
+		}
+		start, end = b.min, b.max
+	case len(lowAndHigh) == 1:
+		v, err := parseValue(lowAndHigh[0], b)
+		if err != nil {
+			return 0, err
+		}
+		start, end = v, v
+		if len(rangeAndStep) == 2 {
+			end = b.max
+		}
+	case len(lowAndHigh) == 2:
+		var err error
+		if start, err = parseValue(lowAndHigh[0], b); err != nil {
+			return 0, err
+		}
+		if end, err = parseValue(lowAndHigh[1], b); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("scheduler: invalid range %q", expr)
+	}
+	if start > end {
+		return 0, fmt.Errorf("scheduler: range start greater than end in %q", expr)
+	}
+
+	step := uint(1)
+	if len(rangeAndStep) == 2 {
+		n, err := strconv.ParseUint(rangeAndStep[1], 10, 8)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("scheduler: invalid step in %q", expr)
+		}
+		step = uint(n)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+	return bits, nil
+}
+
+// parseValue 解析数值或英文缩写
+func parseValue(s string, b bounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("scheduler: invalid value %q", s)
+	}
+	if uint(n) < b.min || uint(n) > b.max {
+		return 0, fmt.Errorf("scheduler: value %d out of range [%d, %d]", n, b.min, b.max)
+	}
+	return uint(n), nil
+}
+
+// Next 返回 t 之后的下一次执行时间，按 t 的时区计算
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	added := false
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for c.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !c.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for c.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for c.minute&(1<<uint(t.Minute())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for c.second&(1<<uint(t.Second())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+	return t
+}
+
+// dayMatches 判断日期是否匹配，日与周同时限定时满足其一即可
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// everySchedule 固定间隔调度规则
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Every 固定间隔调度
+//
+// @Description: 每隔 interval 执行一次，interval 小于等于0时按1秒处理
+// @param interval 执行间隔
+// @return Schedule 调度规则
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return everySchedule{interval: interval}
+}
+
+// Next 返回 t 之后的下一次执行时间
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(e.interval)
+}
+
+// onceSchedule 一次性调度规则
+type onceSchedule struct {
+	at time.Time
+}
+
+// At 在指定时间执行一次
+//
+// @Description: 在指定时间执行一次，时间已过时不会执行
+// @param at 执行时间
+// @return Schedule 调度规则
+func At(at time.Time) Schedule {
+	return onceSchedule{at: at}
+}
+
+// Next 返回执行时间，已过期时返回零值
+func (o onceSchedule) Next(t time.Time) time.Time {
+	if o.at.After(t) {
+		return o.at
+	}
+	return time.Time{}
+}
@@ -0,0 +1,373 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xierui921326/toolkit/worker_pool"
+)
+
+var (
+	// ErrEntryExists 同名调度已存在
+	ErrEntryExists = errors.New("scheduler: entry already exists")
+	// ErrEntryNotFound 调度不存在
+	ErrEntryNotFound = errors.New("scheduler: entry not found")
+)
+
+// OverlapPolicy 上一次执行尚未结束时的处理策略
+type OverlapPolicy int
+
+const (
+	// OverlapSkip 跳过本次执行
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue 排队，等上一次执行结束后再执行
+	OverlapQueue
+	// OverlapAllow 允许并发执行
+	OverlapAllow
+)
+
+// EntryOption 调度可选配置
+type EntryOption func(e *entry)
+
+// WithOverlap 设置重叠执行策略，默认 OverlapSkip
+//
+// @Description: 设置上一次执行尚未结束时的处理策略
+// @param policy 重叠执行策略
+// @return EntryOption
+func WithOverlap(policy OverlapPolicy) EntryOption {
+	return func(e *entry) {
+		e.overlap = policy
+	}
+}
+
+// WithLocation 设置调度时区，默认 time.Local
+//
+// @Description: cron 表达式按该时区计算执行时间
+// @param loc 时区
+// @return EntryOption
+func WithLocation(loc *time.Location) EntryOption {
+	return func(e *entry) {
+		if loc != nil {
+			e.loc = loc
+		}
+	}
+}
+
+// EntryInfo 调度信息
+type EntryInfo struct {
+	Name     string    `json:"name"`
+	Next     time.Time `json:"next"`     //下一次执行时间，零值表示不再执行
+	Prev     time.Time `json:"prev"`     //上一次触发时间
+	Paused   bool      `json:"paused"`   //是否已暂停
+	Running  int       `json:"running"`  //正在执行的次数
+	Queued   int       `json:"queued"`   //排队等待执行的次数
+	Skipped  int64     `json:"skipped"`  //因重叠被跳过的次数
+	Location string    `json:"location"` //时区
+}
+
+// entry 调度项
+type entry struct {
+	name     string
+	schedule Schedule
+	job      worker_pool.Job
+	overlap  OverlapPolicy
+	loc      *time.Location
+	next     time.Time
+	prev     time.Time
+	paused   bool
+	removed  bool
+	running  int
+	queued   int
+	skipped  int64
+}
+
+// Scheduler 调度器，按调度规则将任务提交到线程池执行
+type Scheduler struct {
+	pool    *worker_pool.WorkerPool
+	mu      sync.Mutex
+	entries map[string]*entry
+	wake    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	now     func() time.Time
+}
+
+// New 创建调度器
+//
+// @Description: 创建调度器，任务在传入的线程池上执行，线程池需由调用方 Run 和 Stop
+// @param pool 线程池
+// @return *Scheduler
+func New(pool *worker_pool.WorkerPool) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		pool:    pool,
+		entries: make(map[string]*entry),
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+		now:     time.Now,
+	}
+}
+
+// Add 添加调度
+//
+// @Description: 添加调度，name 需唯一
+// @param name 调度名称
+// @param schedule 调度规则，如 Cron、Every、At
+// @param job 任务
+// @param opts 可选配置，如 WithOverlap、WithLocation
+// @return error 同名调度已存在时返回 ErrEntryExists
+func (s *Scheduler) Add(name string, schedule Schedule, job worker_pool.Job, opts ...EntryOption) error {
+	e := &entry{
+		name:     name,
+		schedule: schedule,
+		job:      job,
+		overlap:  OverlapSkip,
+		loc:      time.Local,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	s.mu.Lock()
+	if _, ok := s.entries[name]; ok {
+		s.mu.Unlock()
+		return ErrEntryExists
+	}
+	e.next = e.schedule.Next(s.now().In(e.loc))
+	s.entries[name] = e
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// AddCron 按 cron 表达式添加调度
+//
+// @Description: 按 cron 表达式添加调度，表达式语法见 Cron
+// @param name 调度名称
+// @param spec cron 表达式
+// @param job 任务
+// @param opts 可选配置
+// @return error
+func (s *Scheduler) AddCron(name, spec string, job worker_pool.Job, opts ...EntryOption) error {
+	schedule, err := Cron(spec)
+	if err != nil {
+		return err
+	}
+	return s.Add(name, schedule, job, opts...)
+}
+
+// Remove 删除调度，正在执行的任务不受影响
+//
+// @Description: 删除调度
+// @param name 调度名称
+// @return error 调度不存在时返回 ErrEntryNotFound
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrEntryNotFound
+	}
+	e.removed = true
+	e.queued = 0
+	delete(s.entries, name)
+	return nil
+}
+
+// Pause 暂停调度
+//
+// @Description: 暂停调度，排队中的执行会被清空
+// @param name 调度名称
+// @return error
+func (s *Scheduler) Pause(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	if !ok {
+		return ErrEntryNotFound
+	}
+	e.paused = true
+	e.queued = 0
+	return nil
+}
+
+// Resume 恢复调度
+//
+// @Description: 恢复调度，从当前时间重新计算下一次执行时间
+// @param name 调度名称
+// @return error
+func (s *Scheduler) Resume(name string) error {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrEntryNotFound
+	}
+	if e.paused {
+		e.paused = false
+		e.next = e.schedule.Next(s.now().In(e.loc))
+	}
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// List 获取所有调度信息，按名称排序
+//
+// @Description: 获取所有调度信息
+// @return []EntryInfo
+func (s *Scheduler) List() []EntryInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]EntryInfo, 0, len(s.entries))
+	for _, e := range s.entries {
+		infos = append(infos, EntryInfo{
+			Name:     e.name,
+			Next:     e.next,
+			Prev:     e.prev,
+			Paused:   e.paused,
+			Running:  e.running,
+			Queued:   e.queued,
+			Skipped:  e.skipped,
+			Location: e.loc.String(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Start 启动调度器
+func (s *Scheduler) Start() {
+	go s.run()
+}
+
+// Stop 停止调度器，已提交到线程池的任务不受影响
+func (s *Scheduler) Stop() {
+	s.cancel()
+}
+
+// notify 唤醒调度协程重新计算等待时间
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 调度循环
+func (s *Scheduler) run() {
+	for {
+		var (
+			timer *time.Timer
+			fired <-chan time.Time
+		)
+		if wait := s.tick(); wait >= 0 {
+			timer = time.NewTimer(wait)
+			fired = timer.C
+		}
+		select {
+		case <-fired:
+		case <-s.wake:
+		case <-s.ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if s.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// tick 触发所有到期的调度，返回距下一次触发的等待时间，没有待执行的调度时返回 -1
+func (s *Scheduler) tick() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var nextWake time.Time
+	for _, e := range s.entries {
+		if e.paused || e.next.IsZero() {
+			continue
+		}
+		if !e.next.After(now) {
+			e.prev = e.next
+			s.fire(e)
+			e.next = e.schedule.Next(now.In(e.loc))
+		}
+		if !e.next.IsZero() && (nextWake.IsZero() || e.next.Before(nextWake)) {
+			nextWake = e.next
+		}
+	}
+	if nextWake.IsZero() {
+		return -1
+	}
+	return nextWake.Sub(now)
+}
+
+// fire 按重叠策略触发一次执行，调用方需持有锁
+func (s *Scheduler) fire(e *entry) {
+	if e.running > 0 {
+		switch e.overlap {
+		case OverlapSkip:
+			e.skipped++
+			return
+		case OverlapQueue:
+			e.queued++
+			return
+		}
+	}
+	e.running++
+	s.submit(e)
+}
+
+// finish 一次执行结束，按需启动排队中的执行
+func (s *Scheduler) finish(e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.running--
+	if e.queued > 0 && !e.removed && !e.paused {
+		e.queued--
+		e.running++
+		s.submit(e)
+	}
+}
+
+// submit 异步提交任务到线程池，避免阻塞调度循环
+func (s *Scheduler) submit(e *entry) {
+	job := &scheduledJob{scheduler: s, entry: e}
+	go func() {
+		if err := s.pool.AddContext(s.ctx, job); err != nil {
+			s.finish(e)
+		}
+	}()
+}
+
+// scheduledJob 包装调度任务，执行结束后通知调度器
+type scheduledJob struct {
+	scheduler *Scheduler
+	entry     *entry
+}
+
+// Do 执行任务
+func (j *scheduledJob) Do() {
+	_ = j.DoContext(context.Background())
+}
+
+// DoContext 执行任务，原任务为 ContextJob 时透传上下文与错误
+func (j *scheduledJob) DoContext(ctx context.Context) error {
+	defer j.scheduler.finish(j.entry)
+	if cj, ok := j.entry.job.(worker_pool.ContextJob); ok {
+		return cj.DoContext(ctx)
+	}
+	j.entry.job.Do()
+	return nil
+}
+
+// JobType 以调度名称作为任务类型，便于在线程池统计中区分
+func (j *scheduledJob) JobType() string {
+	return "scheduler." + j.entry.name
+}
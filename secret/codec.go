@@ -0,0 +1,262 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+)
+
+const (
+	// Base62Alphabet 数字、小写字母、大写字母组成的 62 进制字母表
+	Base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// Base32Alphabet 数字与小写字母 a-v 组成的 32 进制字母表
+	Base32Alphabet = "0123456789abcdefghijklmnopqrstuv"
+	// Base58Alphabet 去掉易混淆字符 0OIl 的 58 进制字母表
+	Base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+var (
+	// ErrInvalidAlphabet 字母表不合法
+	ErrInvalidAlphabet = errors.New("secret: alphabet must have 2 to 256 unique single-byte characters")
+	// ErrInvalidCode 编码串不合法
+	ErrInvalidCode = errors.New("secret: invalid code")
+	// ErrCodeOverflow 编码串超出 uint64 范围
+	ErrCodeOverflow = errors.New("secret: code overflows uint64")
+)
+
+// Codec 可逆的 N 进制编解码器
+//
+// 使用整数运算精确转换 uint64 与任意精度整数。设置盐值后会打乱字母表，
+// 并按数值选择一个前缀字符再次打乱正文字母表(类似 Hashids)，
+// 使相邻 ID 的编码看起来无规律，但不具备加密强度
+type Codec struct {
+	alphabet  []byte
+	index     [256]int16
+	salt      []byte
+	minLength int
+}
+
+// CodecOption Codec 可选配置
+type CodecOption func(*Codec)
+
+// WithSalt 设置盐值，开启混淆
+//
+// @param salt 盐值，相同的字母表和盐值才能互相解码
+func WithSalt(salt string) CodecOption {
+	return func(c *Codec) {
+		c.salt = []byte(salt)
+	}
+}
+
+// WithMinLength 设置编码串的最小长度，不足时在高位补齐
+//
+// @param n 最小长度
+func WithMinLength(n int) CodecOption {
+	return func(c *Codec) {
+		c.minLength = n
+	}
+}
+
+// NewCodec 创建 N 进制编解码器
+//
+// @Description: 进制等于字母表长度
+// @param alphabet 字母表，如 Base62Alphabet
+// @param opts 可选配置，如 WithSalt、WithMinLength
+// @return *Codec
+// @return error 字母表重复或长度不合法时返回 ErrInvalidAlphabet
+func NewCodec(alphabet string, opts ...CodecOption) (*Codec, error) {
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return nil, ErrInvalidAlphabet
+	}
+	c := &Codec{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.alphabet = shuffle([]byte(alphabet), c.salt)
+	if err := c.buildIndex(c.alphabet); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// MustCodec 创建 N 进制编解码器，参数错误时 panic
+func MustCodec(alphabet string, opts ...CodecOption) *Codec {
+	c, err := NewCodec(alphabet, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Base 进制
+func (c *Codec) Base() int {
+	return len(c.alphabet)
+}
+
+// Encode 编码 uint64
+//
+// @param n 数值
+// @return string 编码串
+func (c *Codec) Encode(n uint64) string {
+	base := uint64(len(c.alphabet))
+	digits := make([]int, 0, 16)
+	for {
+		digits = append(digits, int(n%base))
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+	return c.render(digits)
+}
+
+// EncodeBig 编码任意精度非负整数
+//
+// @param n 数值
+// @return string 编码串
+// @return error n 为空或负数时返回错误
+func (c *Codec) EncodeBig(n *big.Int) (string, error) {
+	if n == nil || n.Sign() < 0 {
+		return "", fmt.Errorf("secret: cannot encode negative or nil integer")
+	}
+	base := big.NewInt(int64(len(c.alphabet)))
+	q, r := new(big.Int).Set(n), new(big.Int)
+	var digits []int
+	for {
+		q.QuoRem(q, base, r)
+		digits = append(digits, int(r.Int64()))
+		if q.Sign() == 0 {
+			break
+		}
+	}
+	return c.render(digits), nil
+}
+
+// Decode 解码为 uint64
+//
+// @Description: 只接受 Encode 生成的规范编码串
+// @param code 编码串
+// @return uint64 数值
+// @return error 编码串不合法返回 ErrInvalidCode，超出范围返回 ErrCodeOverflow
+func (c *Codec) Decode(code string) (uint64, error) {
+	digits, err := c.parse(code)
+	if err != nil {
+		return 0, err
+	}
+	base := uint64(len(c.alphabet))
+	var n uint64
+	for _, d := range digits {
+		hi, lo := bits.Mul64(n, base)
+		var carry uint64
+		n, carry = bits.Add64(lo, uint64(d), 0)
+		if hi != 0 || carry != 0 {
+			return 0, ErrCodeOverflow
+		}
+	}
+	if c.Encode(n) != code {
+		return 0, ErrInvalidCode
+	}
+	return n, nil
+}
+
+// DecodeBig 解码为任意精度整数
+//
+// @param code 编码串
+// @return *big.Int 数值
+// @return error 编码串不合法返回 ErrInvalidCode
+func (c *Codec) DecodeBig(code string) (*big.Int, error) {
+	digits, err := c.parse(code)
+	if err != nil {
+		return nil, err
+	}
+	base := big.NewInt(int64(len(c.alphabet)))
+	n, d := new(big.Int), new(big.Int)
+	for _, v := range digits {
+		n.Mul(n, base).Add(n, d.SetInt64(int64(v)))
+	}
+	if s, _ := c.EncodeBig(n); s != code {
+		return nil, ErrInvalidCode
+	}
+	return n, nil
+}
+
+// render 将低位在前的数字序列渲染为编码串
+func (c *Codec) render(digits []int) string {
+	alphabet := c.alphabet
+	var buf []byte
+	if len(c.salt) > 0 {
+		//以最低位数字选出前缀字符，并用它打乱正文字母表
+		prefix := c.alphabet[digits[0]]
+		buf = append(buf, prefix)
+		alphabet = c.bodyAlphabet(prefix)
+	}
+	for len(digits)+len(buf) < c.minLength {
+		digits = append(digits, 0)
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		buf = append(buf, alphabet[digits[i]])
+	}
+	return string(buf)
+}
+
+// parse 将编码串解析为高位在前的数字序列
+func (c *Codec) parse(code string) ([]int, error) {
+	if code == "" {
+		return nil, ErrInvalidCode
+	}
+	index := &c.index
+	if len(c.salt) > 0 {
+		if c.index[code[0]] < 0 || len(code) < 2 {
+			return nil, ErrInvalidCode
+		}
+		body := &Codec{}
+		_ = body.buildIndex(c.bodyAlphabet(code[0]))
+		index = &body.index
+		code = code[1:]
+	}
+	digits := make([]int, len(code))
+	for i := 0; i < len(code); i++ {
+		d := index[code[i]]
+		if d < 0 {
+			return nil, ErrInvalidCode
+		}
+		digits[i] = int(d)
+	}
+	return digits, nil
+}
+
+// bodyAlphabet 混淆模式下按前缀字符打乱的正文字母表
+func (c *Codec) bodyAlphabet(prefix byte) []byte {
+	salt := append([]byte{prefix}, c.salt...)
+	return shuffle(append([]byte(nil), c.alphabet...), salt)
+}
+
+// buildIndex 建立字符到数字的反查表
+func (c *Codec) buildIndex(alphabet []byte) error {
+	for i := range c.index {
+		c.index[i] = -1
+	}
+	for i, ch := range alphabet {
+		if c.index[ch] >= 0 {
+			return ErrInvalidAlphabet
+		}
+		c.index[ch] = int16(i)
+	}
+	return nil
+}
+
+// shuffle 按盐值确定性地打乱字母表
+func shuffle(alphabet, salt []byte) []byte {
+	if len(salt) == 0 {
+		return alphabet
+	}
+	for i, v, p := len(alphabet)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		p += int(salt[v])
+		j := (int(salt[v]) + v + p) % i
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+		v++
+	}
+	return alphabet
+}
@@ -0,0 +1,196 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+const (
+	// AccessKeyPrefix 默认 Access Key 前缀
+	AccessKeyPrefix = "ak_"
+	// SecretKeyPrefix 默认 Secret Key 前缀
+	SecretKeyPrefix = "sk_"
+)
+
+var (
+	// ErrInvalidCredential 凭证格式不合法
+	ErrInvalidCredential = errors.New("secret: invalid credential format")
+	// ErrCredentialChecksum 凭证校验位不匹配，通常是输入错误
+	ErrCredentialChecksum = errors.New("secret: credential checksum mismatch")
+)
+
+// Credential 一对应用凭证
+type Credential struct {
+	AccessKey string `json:"access_key"` //公开的访问标识
+	SecretKey string `json:"secret_key"` //签名密钥，仅在签发时展示一次
+}
+
+// CredentialGenerator 应用凭证生成器
+//
+// 凭证格式为 前缀 + 随机串 + 1 位校验字符，随机串由 crypto/rand 生成，
+// 校验字符使用 Luhn mod N 算法，可以发现单个字符错误和大多数相邻字符交换
+type CredentialGenerator struct {
+	alphabet     string
+	index        [256]int16
+	keyLength    int
+	secretLength int
+	keyPrefix    string
+	secretPrefix string
+}
+
+// CredentialOption CredentialGenerator 可选配置
+type CredentialOption func(*CredentialGenerator)
+
+// WithCredentialAlphabet 设置随机串字母表，默认 Base62Alphabet
+func WithCredentialAlphabet(alphabet string) CredentialOption {
+	return func(g *CredentialGenerator) {
+		g.alphabet = alphabet
+	}
+}
+
+// WithKeyLength 设置 Access Key 随机部分长度，默认 20
+func WithKeyLength(n int) CredentialOption {
+	return func(g *CredentialGenerator) {
+		g.keyLength = n
+	}
+}
+
+// WithSecretLength 设置 Secret Key 随机部分长度，默认 40
+func WithSecretLength(n int) CredentialOption {
+	return func(g *CredentialGenerator) {
+		g.secretLength = n
+	}
+}
+
+// WithPrefixes 设置 Access Key 与 Secret Key 的前缀，默认 ak_ 与 sk_
+func WithPrefixes(keyPrefix, secretPrefix string) CredentialOption {
+	return func(g *CredentialGenerator) {
+		g.keyPrefix, g.secretPrefix = keyPrefix, secretPrefix
+	}
+}
+
+// NewCredentialGenerator 创建应用凭证生成器
+//
+// @param opts 可选配置，如 WithKeyLength、WithPrefixes
+// @return *CredentialGenerator
+// @return error 字母表或长度不合法
+func NewCredentialGenerator(opts ...CredentialOption) (*CredentialGenerator, error) {
+	g := &CredentialGenerator{
+		alphabet:     Base62Alphabet,
+		keyLength:    20,
+		secretLength: 40,
+		keyPrefix:    AccessKeyPrefix,
+		secretPrefix: SecretKeyPrefix,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if len(g.alphabet) < 2 || len(g.alphabet) > 256 {
+		return nil, ErrInvalidAlphabet
+	}
+	for i := range g.index {
+		g.index[i] = -1
+	}
+	for i := 0; i < len(g.alphabet); i++ {
+		if g.index[g.alphabet[i]] >= 0 {
+			return nil, ErrInvalidAlphabet
+		}
+		g.index[g.alphabet[i]] = int16(i)
+	}
+	if g.keyLength < 8 || g.secretLength < 16 {
+		return nil, fmt.Errorf("secret: key length must be >= 8 and secret length >= 16")
+	}
+	if g.keyPrefix == g.secretPrefix {
+		return nil, fmt.Errorf("secret: key and secret prefixes must differ")
+	}
+	return g, nil
+}
+
+// Generate 签发一对凭证
+//
+// @return Credential
+// @return error 读取系统随机数失败
+func (g *CredentialGenerator) Generate() (Credential, error) {
+	key, err := g.token(g.keyPrefix, g.keyLength)
+	if err != nil {
+		return Credential{}, err
+	}
+	sec, err := g.token(g.secretPrefix, g.secretLength)
+	if err != nil {
+		return Credential{}, err
+	}
+	return Credential{AccessKey: key, SecretKey: sec}, nil
+}
+
+// ValidateAccessKey 校验 Access Key 的格式与校验位
+func (g *CredentialGenerator) ValidateAccessKey(key string) error {
+	return g.validate(key, g.keyPrefix, g.keyLength)
+}
+
+// ValidateSecretKey 校验 Secret Key 的格式与校验位
+func (g *CredentialGenerator) ValidateSecretKey(secret string) error {
+	return g.validate(secret, g.secretPrefix, g.secretLength)
+}
+
+// token 生成 前缀 + 随机串 + 校验位
+func (g *CredentialGenerator) token(prefix string, length int) (string, error) {
+	body, err := utils.RandomString(g.alphabet, length)
+	if err != nil {
+		return "", err
+	}
+	return prefix + body + string(g.alphabet[g.checksum(body)]), nil
+}
+
+// validate 校验凭证
+func (g *CredentialGenerator) validate(s, prefix string, length int) error {
+	body, ok := strings.CutPrefix(s, prefix)
+	if !ok || len(body) != length+1 {
+		return ErrInvalidCredential
+	}
+	for i := 0; i < len(body); i++ {
+		if g.index[body[i]] < 0 {
+			return ErrInvalidCredential
+		}
+	}
+	if int(g.index[body[length]]) != g.checksum(body[:length]) {
+		return ErrCredentialChecksum
+	}
+	return nil
+}
+
+// checksum Luhn mod N 校验字符下标
+func (g *CredentialGenerator) checksum(body string) int {
+	n := len(g.alphabet)
+	factor, sum := 2, 0
+	for i := len(body) - 1; i >= 0; i-- {
+		addend := factor * int(g.index[body[i]])
+		addend = addend/n + addend%n
+		sum += addend
+		factor = 3 - factor
+	}
+	return (n - sum%n) % n
+}
+
+var defaultCredentialGenerator, _ = NewCredentialGenerator()
+
+// NewCredential 使用默认配置签发一对凭证
+//
+// @Description: Access Key 形如 ak_ + 20 位随机串 + 校验位，Secret Key 形如 sk_ + 40 位随机串 + 校验位
+// @return Credential
+// @return error
+func NewCredential() (Credential, error) {
+	return defaultCredentialGenerator.Generate()
+}
+
+// ValidateAccessKey 按默认配置校验 Access Key
+func ValidateAccessKey(key string) error {
+	return defaultCredentialGenerator.ValidateAccessKey(key)
+}
+
+// ValidateSecretKey 按默认配置校验 Secret Key
+func ValidateSecretKey(secret string) error {
+	return defaultCredentialGenerator.ValidateSecretKey(secret)
+}
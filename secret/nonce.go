@@ -0,0 +1,131 @@
+package secret
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// NonceStore nonce 防重放存储
+//
+// 验签通过后以 Access Key + nonce 为键写入，有效期内重复写入即视为重放。
+// 多实例部署时可基于 Redis SET NX PX 等实现共享存储
+type NonceStore interface {
+	// Add 记录 nonce，ttl 内已存在时返回 false
+	Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// nonceShards 内存 nonce 存储的分片数
+const nonceShards = 32
+
+// nonceShard nonce 分片
+type nonceShard struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+	adds int
+}
+
+// sweep 删除过期 nonce
+func (s *nonceShard) sweep(now time.Time) {
+	for k, exp := range s.seen {
+		if !now.Before(exp) {
+			delete(s.seen, k)
+		}
+	}
+}
+
+// MemoryNonceStore 分片的内存 nonce 存储
+//
+// 按 nonce 哈希分片以减少锁竞争；每个分片每写入1024次顺带清理一次过期 nonce，
+// 也可以通过 NewMemoryNonceStore 的 sweepInterval 启动后台定时清理
+type MemoryNonceStore struct {
+	shards [nonceShards]nonceShard
+	stop   chan struct{}
+	once   sync.Once
+	// Now 当前时间，测试时可替换
+	Now func() time.Time
+}
+
+// NewMemoryNonceStore 创建内存 nonce 存储
+//
+// @Description: sweepInterval 大于0时启动后台清理协程，不再使用时需调用 Close
+// @param sweepInterval 后台清理间隔，0表示只在写入时清理
+// @return *MemoryNonceStore
+func NewMemoryNonceStore(sweepInterval time.Duration) *MemoryNonceStore {
+	m := &MemoryNonceStore{stop: make(chan struct{}), Now: time.Now}
+	for i := range m.shards {
+		m.shards[i].seen = make(map[string]time.Time)
+	}
+	if sweepInterval > 0 {
+		go m.run(sweepInterval)
+	}
+	return m
+}
+
+// Add 记录 nonce
+func (m *MemoryNonceStore) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	now := m.Now()
+	s := m.shard(nonce)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if exp, ok := s.seen[nonce]; ok && now.Before(exp) {
+		return false, nil
+	}
+	s.seen[nonce] = now.Add(ttl)
+	if s.adds++; s.adds%1024 == 0 {
+		s.sweep(now)
+	}
+	return true, nil
+}
+
+// Len 当前保存的 nonce 数量(含尚未清理的过期 nonce)
+func (m *MemoryNonceStore) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		n += len(s.seen)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Sweep 立即清理全部分片中的过期 nonce
+func (m *MemoryNonceStore) Sweep() {
+	now := m.Now()
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		s.sweep(now)
+		s.mu.Unlock()
+	}
+}
+
+// Close 停止后台清理协程
+func (m *MemoryNonceStore) Close() {
+	m.once.Do(func() { close(m.stop) })
+}
+
+// run 定时清理
+func (m *MemoryNonceStore) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Sweep()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// shard 按 nonce 哈希选择分片
+func (m *MemoryNonceStore) shard(nonce string) *nonceShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nonce))
+	return &m.shards[h.Sum32()%nonceShards]
+}
+
+var _ NonceStore = (*MemoryNonceStore)(nil)
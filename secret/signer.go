@@ -0,0 +1,361 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+// 签名请求头
+const (
+	HeaderAccessKey   = "X-Access-Key"
+	HeaderTimestamp   = "X-Timestamp" //Unix 秒级时间戳
+	HeaderNonce       = "X-Nonce"
+	HeaderContentHash = "X-Content-Sha256" //请求体 sha256 的16进制
+	HeaderSignature   = "X-Signature"
+)
+
+var (
+	// ErrMissingSignature 缺少签名相关请求头
+	ErrMissingSignature = errors.New("secret: missing signature headers")
+	// ErrSignatureExpired 时间戳超出允许的时钟偏差
+	ErrSignatureExpired = errors.New("secret: signature timestamp outside allowed skew")
+	// ErrBadSignature 签名不匹配
+	ErrBadSignature = errors.New("secret: bad signature")
+	// ErrContentHash 请求体与签名的哈希不一致
+	ErrContentHash = errors.New("secret: content hash mismatch")
+	// ErrNonceReused nonce 已被使用，请求可能被重放
+	ErrNonceReused = errors.New("secret: nonce already used")
+	// ErrBodyTooLarge 请求体超过验签允许的大小
+	ErrBodyTooLarge = errors.New("secret: request body too large")
+)
+
+// CanonicalRequest 待签名的规范请求
+type CanonicalRequest struct {
+	Method      string
+	Path        string //转义后的路径
+	Query       url.Values
+	ContentHash string //请求体 sha256 的16进制
+	Timestamp   int64
+	Nonce       string
+	AccessKey   string
+}
+
+// String 规范请求字符串，各部分以换行分隔
+//
+// @Description: 格式为 METHOD\nPATH\nQUERY\nCONTENT_HASH\nTIMESTAMP\nNONCE\nACCESS_KEY，
+// QUERY 由 utils.SortedQueryValues 按键和值排序，不包含 signature 参数，单值时与 utils.SortedQueryString 一致
+// @return string
+func (c CanonicalRequest) String() string {
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+	return strings.Join([]string{
+		strings.ToUpper(c.Method),
+		path,
+		utils.SortedQueryValues(c.Query),
+		c.ContentHash,
+		strconv.FormatInt(c.Timestamp, 10),
+		c.Nonce,
+		c.AccessKey,
+	}, "\n")
+}
+
+// Sign 使用 secret 计算签名
+//
+// @param secretKey 签名密钥
+// @return string HmacSha256 的16进制
+func (c CanonicalRequest) Sign(secretKey string) string {
+	return utils.HmacSha256ToHex(secretKey, c.String())
+}
+
+// contentHash 请求体 sha256 的16进制
+func contentHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Signer 请求签名器
+type Signer struct {
+	AccessKey string
+	SecretKey string
+	// Now 当前时间，测试时可替换
+	Now func() time.Time
+}
+
+// NewSigner 创建请求签名器
+//
+// @param accessKey Access Key
+// @param secretKey Secret Key
+// @return *Signer
+func NewSigner(accessKey, secretKey string) *Signer {
+	return &Signer{AccessKey: accessKey, SecretKey: secretKey, Now: time.Now}
+}
+
+// Sign 为请求添加签名请求头
+//
+// @Description: 会读取请求体计算哈希，读取后请求体仍可再次发送
+// @param r 请求
+// @return error 读取请求体或生成 nonce 失败
+func (s *Signer) Sign(r *http.Request) error {
+	body, err := bodyForSigning(r)
+	if err != nil {
+		return err
+	}
+	nonce, err := utils.RandomString(Base62Alphabet, 22)
+	if err != nil {
+		return err
+	}
+	c := CanonicalRequest{
+		Method:      r.Method,
+		Path:        r.URL.EscapedPath(),
+		Query:       r.URL.Query(),
+		ContentHash: contentHash(body),
+		Timestamp:   s.Now().Unix(),
+		Nonce:       nonce,
+		AccessKey:   s.AccessKey,
+	}
+	r.Header.Set(HeaderAccessKey, c.AccessKey)
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(c.Timestamp, 10))
+	r.Header.Set(HeaderNonce, c.Nonce)
+	r.Header.Set(HeaderContentHash, c.ContentHash)
+	r.Header.Set(HeaderSignature, c.Sign(s.SecretKey))
+	return nil
+}
+
+// Transport 返回自动签名的 http.RoundTripper
+//
+// @param base 底层 RoundTripper，为空时使用 http.DefaultTransport
+// @return http.RoundTripper
+func (s *Signer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return signingTransport{signer: s, base: base}
+}
+
+// signingTransport 签名 RoundTripper
+type signingTransport struct {
+	signer *Signer
+	base   http.RoundTripper
+}
+
+// RoundTrip 复制请求并签名后发送，不修改调用方的请求
+func (t signingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	if err := t.signer.Sign(r); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(r)
+}
+
+// SecretLookup 按 Access Key 查找签名密钥
+//
+// 轮换期间可返回多个密钥，任一匹配即通过；Access Key 不存在时返回 ErrCredentialNotFound。
+// 注意 AppCredentialStore 只保存哈希，无法用于 HMAC 验签，签名密钥需要另行加密保存
+type SecretLookup func(ctx context.Context, accessKey string) ([]string, error)
+
+// Verifier 请求验签器
+type Verifier struct {
+	lookup  SecretLookup
+	skew    time.Duration
+	maxBody int64
+	nonces  NonceStore
+	// Now 当前时间，测试时可替换
+	Now func() time.Time
+}
+
+// VerifierOption Verifier 可选配置
+type VerifierOption func(*Verifier)
+
+// WithClockSkew 设置允许的时钟偏差，默认5分钟
+func WithClockSkew(d time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.skew = d
+	}
+}
+
+// WithMaxBodySize 设置验签时读取的最大请求体字节数，默认10MB
+func WithMaxBodySize(n int64) VerifierOption {
+	return func(v *Verifier) {
+		v.maxBody = n
+	}
+}
+
+// WithNonceStore 设置 nonce 防重放存储，默认使用进程内的 MemoryNonceStore，
+// 多实例部署时应使用共享存储
+func WithNonceStore(store NonceStore) VerifierOption {
+	return func(v *Verifier) {
+		v.nonces = store
+	}
+}
+
+// NewVerifier 创建请求验签器
+//
+// @param lookup 签名密钥查找函数
+// @param opts 可选配置，如 WithClockSkew
+// @return *Verifier
+func NewVerifier(lookup SecretLookup, opts ...VerifierOption) *Verifier {
+	v := &Verifier{
+		lookup:  lookup,
+		skew:    5 * time.Minute,
+		maxBody: 10 << 20,
+		nonces:  NewMemoryNonceStore(0),
+		Now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify 校验请求签名
+//
+// @Description: 依次校验请求头、时间戳、请求体哈希、签名和 nonce，校验后请求体仍可读取
+// @param r 请求
+// @return string 通过校验的 Access Key
+// @return error
+func (v *Verifier) Verify(r *http.Request) (string, error) {
+	h := r.Header
+	accessKey, nonce, signature := h.Get(HeaderAccessKey), h.Get(HeaderNonce), h.Get(HeaderSignature)
+	if accessKey == "" || nonce == "" || signature == "" || h.Get(HeaderTimestamp) == "" {
+		return "", ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(h.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return "", ErrMissingSignature
+	}
+	now := v.Now()
+	if d := now.Sub(time.Unix(ts, 0)); d > v.skew || d < -v.skew {
+		return "", ErrSignatureExpired
+	}
+
+	body, err := readBody(r, v.maxBody)
+	if err != nil {
+		return "", err
+	}
+	c := CanonicalRequest{
+		Method:      r.Method,
+		Path:        r.URL.EscapedPath(),
+		Query:       r.URL.Query(),
+		ContentHash: contentHash(body),
+		Timestamp:   ts,
+		Nonce:       nonce,
+		AccessKey:   accessKey,
+	}
+	if subtle.ConstantTimeCompare([]byte(c.ContentHash), []byte(h.Get(HeaderContentHash))) != 1 {
+		return "", ErrContentHash
+	}
+
+	secrets, err := v.lookup(r.Context(), accessKey)
+	if err != nil {
+		return "", err
+	}
+	matched := false
+	for _, s := range secrets {
+		if subtle.ConstantTimeCompare([]byte(c.Sign(s)), []byte(signature)) == 1 {
+			matched = true
+		}
+	}
+	if !matched {
+		return "", ErrBadSignature
+	}
+
+	//签名通过后才记录 nonce，避免伪造请求占用 nonce
+	//时间戳最多超前 skew，nonce 至少保留到该请求不再能通过时间校验
+	fresh, err := v.nonces.Add(r.Context(), accessKey+":"+nonce, 2*v.skew)
+	if err != nil {
+		return "", err
+	}
+	if !fresh {
+		return "", ErrNonceReused
+	}
+	return accessKey, nil
+}
+
+// Middleware 验签中间件
+//
+// @Description: 验签失败返回 401，通过时可在处理函数中用 AccessKeyFromContext 获取 Access Key
+// @param next 下一个处理器
+// @return http.Handler
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessKey, err := v.Verify(r)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, ErrBodyTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), accessKeyContextKey{}, accessKey)))
+	})
+}
+
+// accessKeyContextKey 上下文中 Access Key 的键
+type accessKeyContextKey struct{}
+
+// AccessKeyFromContext 获取验签中间件写入的 Access Key
+func AccessKeyFromContext(ctx context.Context) (string, bool) {
+	accessKey, ok := ctx.Value(accessKeyContextKey{}).(string)
+	return accessKey, ok
+}
+
+// bodyForSigning 客户端请求体，优先使用 GetBody 获取副本
+func bodyForSigning(r *http.Request) ([]byte, error) {
+	if r.GetBody == nil || r.Body == nil || r.Body == http.NoBody {
+		return readBody(r, -1)
+	}
+	rc, err := r.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// readBody 读取请求体并重置，使其可以再次读取；limit 小于0时不限制
+func readBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := readLimited(r.Body, limit)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// readLimited 读取最多 limit 字节，超出时返回 ErrBodyTooLarge
+func readLimited(rd io.Reader, limit int64) ([]byte, error) {
+	if limit < 0 {
+		return io.ReadAll(rd)
+	}
+	body, err := io.ReadAll(io.LimitReader(rd, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("secret: read body: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
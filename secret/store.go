@@ -0,0 +1,372 @@
+package secret
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+var (
+	// ErrCredentialNotFound 应用或 secret 不存在
+	ErrCredentialNotFound = errors.New("secret: credential not found")
+	// ErrSecretMismatch 应用不存在或 secret 与应用下任何有效 secret 都不匹配
+	ErrSecretMismatch = errors.New("secret: secret mismatch")
+)
+
+// SecretInfo secret 元数据，不包含明文与哈希
+type SecretInfo struct {
+	ID        string    `json:"id"`
+	AppID     string    `json:"app_id"`
+	Hint      string    `json:"hint"`                 //明文末尾4位(不含校验字符)，便于人工辨认
+	CreatedAt time.Time `json:"created_at"`           //签发时间
+	ExpiresAt time.Time `json:"expires_at,omitempty"` //过期时间，零值表示永不过期
+	RevokedAt time.Time `json:"revoked_at,omitempty"` //吊销时间，零值表示未吊销
+}
+
+// Active 在 now 时刻是否有效
+func (s SecretInfo) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && (s.ExpiresAt.IsZero() || now.Before(s.ExpiresAt))
+}
+
+// AppCredentialStore 应用凭证存储
+//
+// 只保存加盐哈希，明文 secret 仅在 Issue/Rotate 时返回一次。
+// 同一应用可以同时有多个有效 secret，用于轮换期间新旧 secret 并存
+type AppCredentialStore interface {
+	// Issue 为应用签发新 secret，ttl 为0时永不过期
+	Issue(ctx context.Context, appID string, ttl time.Duration) (string, SecretInfo, error)
+	// Import 导入已有的明文 secret(如 GetAppSecret 的历史数据)，只保存其哈希
+	Import(ctx context.Context, appID, secret string, ttl time.Duration) (SecretInfo, error)
+	// Rotate 签发新 secret，并让其他有效 secret 在 grace 后过期
+	Rotate(ctx context.Context, appID string, ttl, grace time.Duration) (string, SecretInfo, error)
+	// Verify 校验 secret，匹配任一有效 secret 时返回其元数据；应用不存在或不匹配时都返回 ErrSecretMismatch
+	Verify(ctx context.Context, appID, secret string) (SecretInfo, error)
+	// List 列出应用的全部 secret(含已过期和已吊销)，按签发时间排序
+	List(ctx context.Context, appID string) ([]SecretInfo, error)
+	// Revoke 吊销 secret
+	Revoke(ctx context.Context, appID, secretID string) error
+}
+
+// storedSecret 持久化的 secret 记录
+type storedSecret struct {
+	SecretInfo
+	Salt string `json:"salt"`
+	Hash string `json:"hash"` //hex(sha256(salt + secret))
+}
+
+// match 常数时间比较 secret
+func (s *storedSecret) match(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashSecret(s.Salt, secret)), []byte(s.Hash)) == 1
+}
+
+// hashSecret 计算加盐哈希；secret 为高熵随机串，无需慢哈希
+func hashSecret(salt, secret string) string {
+	sum := sha256.Sum256([]byte(salt + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// dummySecret 应用不存在时参与比较的哈希，使校验耗时与应用是否存在无关
+var dummySecret = storedSecret{Salt: strings.Repeat("0", 32), Hash: hashSecret(strings.Repeat("0", 32), "")}
+
+// secretHint 取明文末尾4位作为提示；由 g 签发的 secret 去掉末尾的校验字符，避免提示中包含可推算的位
+func secretHint(g *CredentialGenerator, secret string) string {
+	if g != nil && g.ValidateSecretKey(secret) == nil {
+		secret = secret[:len(secret)-1]
+	}
+	if len(secret) <= 4 {
+		return ""
+	}
+	return secret[len(secret)-4:]
+}
+
+// credentialTable 凭证表，MemoryCredentialStore 与 FileCredentialStore 共用
+type credentialTable map[string][]*storedSecret
+
+// add 保存 secret 的哈希
+func (t credentialTable) add(g *CredentialGenerator, appID, secret string, ttl time.Duration, now time.Time) (SecretInfo, error) {
+	if appID == "" || secret == "" {
+		return SecretInfo{}, fmt.Errorf("secret: app id and secret are required")
+	}
+	id, err := utils.RandomString(Base62Alphabet, 16)
+	if err != nil {
+		return SecretInfo{}, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return SecretInfo{}, err
+	}
+	s := &storedSecret{
+		SecretInfo: SecretInfo{ID: id, AppID: appID, Hint: secretHint(g, secret), CreatedAt: now},
+		Salt:       hex.EncodeToString(salt),
+	}
+	if ttl > 0 {
+		s.ExpiresAt = now.Add(ttl)
+	}
+	s.Hash = hashSecret(s.Salt, secret)
+	t[appID] = append(t[appID], s)
+	return s.SecretInfo, nil
+}
+
+// issue 生成并保存新 secret
+func (t credentialTable) issue(g *CredentialGenerator, appID string, ttl time.Duration, now time.Time) (string, SecretInfo, error) {
+	cred, err := g.Generate()
+	if err != nil {
+		return "", SecretInfo{}, err
+	}
+	info, err := t.add(g, appID, cred.SecretKey, ttl, now)
+	if err != nil {
+		return "", SecretInfo{}, err
+	}
+	return cred.SecretKey, info, nil
+}
+
+// rotate 签发新 secret 并缩短其他有效 secret 的有效期
+func (t credentialTable) rotate(g *CredentialGenerator, appID string, ttl, grace time.Duration, now time.Time) (string, SecretInfo, error) {
+	deadline := now.Add(grace)
+	for _, s := range t[appID] {
+		if s.Active(now) && (s.ExpiresAt.IsZero() || s.ExpiresAt.After(deadline)) {
+			s.ExpiresAt = deadline
+		}
+	}
+	return t.issue(g, appID, ttl, now)
+}
+
+// verify 校验 secret，遍历全部有效 secret 以免泄露匹配位置；
+// 应用不存在时同样做一次哈希比较并返回 ErrSecretMismatch，不泄露应用是否存在
+func (t credentialTable) verify(appID, secret string, now time.Time) (SecretInfo, error) {
+	secrets, ok := t[appID]
+	if !ok {
+		dummySecret.match(secret)
+		return SecretInfo{}, ErrSecretMismatch
+	}
+	var found *storedSecret
+	for _, s := range secrets {
+		if s.match(secret) && s.Active(now) && found == nil {
+			found = s
+		}
+	}
+	if found == nil {
+		return SecretInfo{}, ErrSecretMismatch
+	}
+	return found.SecretInfo, nil
+}
+
+// list 列出应用的 secret 元数据
+func (t credentialTable) list(appID string) []SecretInfo {
+	result := make([]SecretInfo, 0, len(t[appID]))
+	for _, s := range t[appID] {
+		result = append(result, s.SecretInfo)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result
+}
+
+// revoke 吊销 secret，重复吊销不报错
+func (t credentialTable) revoke(appID, secretID string, now time.Time) error {
+	for _, s := range t[appID] {
+		if s.ID == secretID {
+			if s.RevokedAt.IsZero() {
+				s.RevokedAt = now
+			}
+			return nil
+		}
+	}
+	return ErrCredentialNotFound
+}
+
+// MemoryCredentialStore 内存应用凭证存储，适用于测试和单进程场景
+type MemoryCredentialStore struct {
+	mu      sync.Mutex
+	secrets credentialTable
+	// Generator 签发 secret 使用的生成器，默认使用 NewCredential 的配置
+	Generator *CredentialGenerator
+	// Now 当前时间，测试时可替换
+	Now func() time.Time
+}
+
+// NewMemoryCredentialStore 创建内存应用凭证存储
+func NewMemoryCredentialStore() *MemoryCredentialStore {
+	return &MemoryCredentialStore{
+		secrets:   make(credentialTable),
+		Generator: defaultCredentialGenerator,
+		Now:       time.Now,
+	}
+}
+
+// Issue 签发新 secret
+func (m *MemoryCredentialStore) Issue(ctx context.Context, appID string, ttl time.Duration) (string, SecretInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.secrets.issue(m.Generator, appID, ttl, m.Now())
+}
+
+// Import 导入已有的明文 secret
+func (m *MemoryCredentialStore) Import(ctx context.Context, appID, secret string, ttl time.Duration) (SecretInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.secrets.add(m.Generator, appID, secret, ttl, m.Now())
+}
+
+// Rotate 轮换 secret
+func (m *MemoryCredentialStore) Rotate(ctx context.Context, appID string, ttl, grace time.Duration) (string, SecretInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.secrets.rotate(m.Generator, appID, ttl, grace, m.Now())
+}
+
+// Verify 校验 secret
+func (m *MemoryCredentialStore) Verify(ctx context.Context, appID, secret string) (SecretInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.secrets.verify(appID, secret, m.Now())
+}
+
+// List 列出应用的 secret
+func (m *MemoryCredentialStore) List(ctx context.Context, appID string) ([]SecretInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.secrets.list(appID), nil
+}
+
+// Revoke 吊销 secret
+func (m *MemoryCredentialStore) Revoke(ctx context.Context, appID, secretID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.secrets.revoke(appID, secretID, m.Now())
+}
+
+// FileCredentialStore 基于 JSON 文件的应用凭证存储
+//
+// 每次操作都重新读取文件，修改后先写临时文件再重命名，保证文件不会写坏；
+// 只在进程内加锁，多个进程共享同一文件时请使用数据库等实现
+type FileCredentialStore struct {
+	mu   sync.Mutex
+	path string
+	// Generator 签发 secret 使用的生成器，默认使用 NewCredential 的配置
+	Generator *CredentialGenerator
+}
+
+// NewFileCredentialStore 创建基于 JSON 文件的应用凭证存储
+//
+// @Description: 文件所在目录不存在时自动创建，文件权限为 0600
+// @param path 文件路径
+// @return *FileCredentialStore
+// @return error
+func NewFileCredentialStore(path string) (*FileCredentialStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("secret: create store dir: %w", err)
+	}
+	return &FileCredentialStore{path: path, Generator: defaultCredentialGenerator}, nil
+}
+
+// Path 存储文件路径
+func (f *FileCredentialStore) Path() string {
+	return f.path
+}
+
+// Issue 签发新 secret
+func (f *FileCredentialStore) Issue(ctx context.Context, appID string, ttl time.Duration) (secret string, info SecretInfo, err error) {
+	err = f.update(func(t credentialTable) error {
+		secret, info, err = t.issue(f.Generator, appID, ttl, time.Now())
+		return err
+	})
+	return secret, info, err
+}
+
+// Import 导入已有的明文 secret
+func (f *FileCredentialStore) Import(ctx context.Context, appID, secret string, ttl time.Duration) (info SecretInfo, err error) {
+	err = f.update(func(t credentialTable) error {
+		info, err = t.add(f.Generator, appID, secret, ttl, time.Now())
+		return err
+	})
+	return info, err
+}
+
+// Rotate 轮换 secret
+func (f *FileCredentialStore) Rotate(ctx context.Context, appID string, ttl, grace time.Duration) (secret string, info SecretInfo, err error) {
+	err = f.update(func(t credentialTable) error {
+		secret, info, err = t.rotate(f.Generator, appID, ttl, grace, time.Now())
+		return err
+	})
+	return secret, info, err
+}
+
+// Verify 校验 secret
+func (f *FileCredentialStore) Verify(ctx context.Context, appID, secret string) (SecretInfo, error) {
+	t, err := f.load()
+	if err != nil {
+		return SecretInfo{}, err
+	}
+	return t.verify(appID, secret, time.Now())
+}
+
+// List 列出应用的 secret
+func (f *FileCredentialStore) List(ctx context.Context, appID string) ([]SecretInfo, error) {
+	t, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return t.list(appID), nil
+}
+
+// Revoke 吊销 secret
+func (f *FileCredentialStore) Revoke(ctx context.Context, appID, secretID string) error {
+	return f.update(func(t credentialTable) error {
+		return t.revoke(appID, secretID, time.Now())
+	})
+}
+
+// load 读取凭证表，文件不存在时返回空表
+func (f *FileCredentialStore) load() (credentialTable, error) {
+	table := make(credentialTable)
+	data, err := os.ReadFile(f.path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &table); err != nil {
+			return nil, fmt.Errorf("secret: decode store file: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("secret: read store file: %w", err)
+	}
+	return table, nil
+}
+
+// update 读取凭证表，执行 fn 成功后写回
+func (f *FileCredentialStore) update(fn func(t credentialTable) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	table, err := f.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(table); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(table, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("secret: write store file: %w", err)
+	}
+	return os.Rename(tmp, f.path)
+}
+
+var (
+	_ AppCredentialStore = (*MemoryCredentialStore)(nil)
+	_ AppCredentialStore = (*FileCredentialStore)(nil)
+)
@@ -0,0 +1,292 @@
+package snow_node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+var (
+	// ErrNoFreeNodeID 没有可用的节点 ID
+	ErrNoFreeNodeID = errors.New("snow_node: no free node id")
+	// ErrLeaseLost 租约已失效(过期后被其他进程获取或已释放)
+	ErrLeaseLost = errors.New("snow_node: node id lease lost")
+	// ErrInvalidTTL 租约时长必须大于0
+	ErrInvalidTTL = errors.New("snow_node: lease ttl must be positive")
+)
+
+// Lease 节点 ID 租约
+type Lease struct {
+	NodeID    int64     `json:"node_id"`
+	Owner     string    `json:"owner"`      //持有者，如主机名+进程号
+	Token     string    `json:"token"`      //租约凭证，续约和释放时校验
+	ExpiresAt time.Time `json:"expires_at"` //过期时间
+}
+
+// NodeIDAllocator 节点 ID 分配器
+//
+// 每个进程通过 Acquire 租用一个唯一的节点 ID，并在过期前通过 Renew 续约；
+// 租约过期后该节点 ID 可被其他进程获取。可基于 Redis、etcd 等实现
+type NodeIDAllocator interface {
+	// Acquire 在 [0, maxNodeID] 内租用一个空闲的节点 ID，没有空闲 ID 时返回 ErrNoFreeNodeID；
+	// maxNodeID 由生成器按布局传入，即 Layout.MaxNodeID()
+	Acquire(ctx context.Context, owner string, maxNodeID int64, ttl time.Duration) (*Lease, error)
+	// Renew 续约，租约已失效时返回 ErrLeaseLost
+	Renew(ctx context.Context, lease *Lease, ttl time.Duration) error
+	// Release 释放租约
+	Release(ctx context.Context, lease *Lease) error
+}
+
+// limitNodeID 取分配器自身上限与调用方上限中较小的一个，own 小于0表示分配器不限制
+func limitNodeID(own, maxNodeID int64) int64 {
+	if own >= 0 && own < maxNodeID {
+		return own
+	}
+	return maxNodeID
+}
+
+// leaseTable 租约表，MemoryAllocator 与 FileAllocator 共用
+type leaseTable map[int64]*Lease
+
+// acquire 从 0 开始查找第一个空闲或已过期的节点 ID
+func (t leaseTable) acquire(owner string, ttl time.Duration, maxNodeID int64, now time.Time) (*Lease, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	for id := int64(0); id <= maxNodeID; id++ {
+		if l, ok := t[id]; ok && now.Before(l.ExpiresAt) {
+			continue
+		}
+		token, err := utils.RandomHex(16)
+		if err != nil {
+			return nil, err
+		}
+		lease := &Lease{NodeID: id, Owner: owner, Token: token, ExpiresAt: now.Add(ttl)}
+		t[id] = lease
+		copied := *lease
+		return &copied, nil
+	}
+	return nil, ErrNoFreeNodeID
+}
+
+// renew 续约并更新 lease 的过期时间
+func (t leaseTable) renew(lease *Lease, ttl time.Duration, now time.Time) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+	l, ok := t[lease.NodeID]
+	if !ok || l.Token != lease.Token {
+		return ErrLeaseLost
+	}
+	l.ExpiresAt = now.Add(ttl)
+	lease.ExpiresAt = l.ExpiresAt
+	return nil
+}
+
+// release 释放租约，租约已不属于调用方时忽略
+func (t leaseTable) release(lease *Lease) {
+	if l, ok := t[lease.NodeID]; ok && l.Token == lease.Token {
+		delete(t, lease.NodeID)
+	}
+}
+
+// MemoryAllocator 内存节点 ID 分配器，用于测试和单进程场景
+type MemoryAllocator struct {
+	mu     sync.Mutex
+	leases leaseTable
+	max    int64
+	// Now 当前时间，测试时可替换
+	Now func() time.Time
+}
+
+// NewMemoryAllocator 创建内存节点 ID 分配器
+//
+// @Description: 创建内存节点 ID 分配器
+// @param maxNodeID 可分配的最大节点 ID，小于0时只受生成器布局的限制
+// @return *MemoryAllocator
+func NewMemoryAllocator(maxNodeID int64) *MemoryAllocator {
+	return &MemoryAllocator{leases: make(leaseTable), max: maxNodeID, Now: time.Now}
+}
+
+// Acquire 租用一个空闲的节点 ID
+func (m *MemoryAllocator) Acquire(ctx context.Context, owner string, maxNodeID int64, ttl time.Duration) (*Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leases.acquire(owner, ttl, limitNodeID(m.max, maxNodeID), m.Now())
+}
+
+// Renew 续约
+func (m *MemoryAllocator) Renew(ctx context.Context, lease *Lease, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leases.renew(lease, ttl, m.Now())
+}
+
+// Release 释放租约
+func (m *MemoryAllocator) Release(ctx context.Context, lease *Lease) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.leases.release(lease)
+	return nil
+}
+
+// FileAllocator 基于文件锁的节点 ID 分配器
+//
+// 租约表保存在目录下的 leases.json 中，每次操作先对 leases.lock 加 flock 排他锁，
+// 持有者崩溃时锁由内核释放。适用于同一主机上的多个进程，仅支持 unix 平台，
+// 其他平台上的操作返回 errors.ErrUnsupported
+type FileAllocator struct {
+	dir string
+	max int64
+}
+
+// NewFileAllocator 创建基于文件锁的节点 ID 分配器
+//
+// @Description: 目录不存在时自动创建
+// @param dir 租约文件目录
+// @param maxNodeID 可分配的最大节点 ID，小于0时只受生成器布局的限制
+// @return *FileAllocator
+// @return error
+func NewFileAllocator(dir string, maxNodeID int64) (*FileAllocator, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("snow_node: create lease dir: %w", err)
+	}
+	return &FileAllocator{dir: dir, max: maxNodeID}, nil
+}
+
+// Acquire 租用一个空闲的节点 ID
+func (f *FileAllocator) Acquire(ctx context.Context, owner string, maxNodeID int64, ttl time.Duration) (*Lease, error) {
+	var lease *Lease
+	err := f.update(ctx, func(t leaseTable) error {
+		var err error
+		lease, err = t.acquire(owner, ttl, limitNodeID(f.max, maxNodeID), time.Now())
+		return err
+	})
+	return lease, err
+}
+
+// Renew 续约
+func (f *FileAllocator) Renew(ctx context.Context, lease *Lease, ttl time.Duration) error {
+	return f.update(ctx, func(t leaseTable) error {
+		return t.renew(lease, ttl, time.Now())
+	})
+}
+
+// Release 释放租约
+func (f *FileAllocator) Release(ctx context.Context, lease *Lease) error {
+	return f.update(ctx, func(t leaseTable) error {
+		t.release(lease)
+		return nil
+	})
+}
+
+// update 加锁读取租约表，执行 fn 成功后写回
+func (f *FileAllocator) update(ctx context.Context, fn func(t leaseTable) error) error {
+	unlock, err := f.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path := filepath.Join(f.dir, "leases.json")
+	table := make(leaseTable)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &table); err != nil {
+			return fmt.Errorf("snow_node: decode lease file: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("snow_node: read lease file: %w", err)
+	}
+
+	if err := fn(table); err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(table)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("snow_node: write lease file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// lock 对锁文件加排他锁，被其他进程持有时等待
+func (f *FileAllocator) lock(ctx context.Context) (func(), error) {
+	file, err := os.OpenFile(filepath.Join(f.dir, "leases.lock"), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("snow_node: open lock file: %w", err)
+	}
+	for {
+		ok, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("snow_node: lock lease file: %w", err)
+		}
+		if ok {
+			return func() {
+				_ = unlockFile(file)
+				_ = file.Close()
+			}, nil
+		}
+		select {
+		case <-ctx.Done():
+			_ = file.Close()
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// KeepAlive 定期续约，直到 ctx 结束或租约失效
+//
+// @Description: 每隔 ttl/3 续约一次；续约返回 ErrLeaseLost 或持续失败到租约过期时调用 onLost 并返回，
+// ttl 小于等于0时立即以 ErrInvalidTTL 调用 onLost 并返回
+// @param ctx 上下文
+// @param alloc 分配器
+// @param lease 租约
+// @param ttl 租约时长
+// @param onLost 租约失效回调，可为空
+func KeepAlive(ctx context.Context, alloc NodeIDAllocator, lease *Lease, ttl time.Duration, onLost func(err error)) {
+	if ttl <= 0 {
+		if onLost != nil {
+			onLost(ErrInvalidTTL)
+		}
+		return
+	}
+	ticker := time.NewTicker(max(ttl/3, time.Nanosecond))
+	defer ticker.Stop()
+	expiresAt := lease.ExpiresAt
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := alloc.Renew(ctx, lease, ttl)
+		if err == nil {
+			expiresAt = lease.ExpiresAt
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, ErrLeaseLost) || !time.Now().Before(expiresAt) {
+			if onLost != nil {
+				onLost(err)
+			}
+			return
+		}
+	}
+}
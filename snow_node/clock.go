@@ -0,0 +1,108 @@
+package snow_node
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultMaxClockWait ClockWait 策略默认最长等待时间
+const defaultMaxClockWait = 5 * time.Second
+
+// ClockPolicy 时钟回拨处理策略
+type ClockPolicy int
+
+const (
+	// ClockWait 等待时钟追上上一次生成 ID 的时间，回拨超过最长等待时间时返回 ErrClockBackwards
+	ClockWait ClockPolicy = iota
+	// ClockError 直接返回 ErrClockBackwards
+	ClockError
+	// ClockLogical 使用逻辑时钟：沿用上一次的时间戳继续递增序号，序号用尽时时间戳加1
+	ClockLogical
+)
+
+// WithLayout 设置位布局
+//
+// @Description: 自定义纪元与节点、序号位数，同一业务的所有节点必须使用相同布局
+// @param layout 位布局
+// @return Option
+func WithLayout(layout Layout) Option {
+	return func(c *config) {
+		c.layout = layout
+	}
+}
+
+// WithEpoch 设置纪元，其余位布局与 DefaultLayout 相同
+//
+// @Description: 设置纪元
+// @param epoch 纪元
+// @return Option
+func WithEpoch(epoch time.Time) Option {
+	return func(c *config) {
+		c.layout.Epoch = epoch
+	}
+}
+
+// WithClockPolicy 设置时钟回拨处理策略，默认 ClockWait
+//
+// @Description: 设置时钟回拨处理策略
+// @param policy 处理策略
+// @return Option
+func WithClockPolicy(policy ClockPolicy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// WithMaxClockWait 设置 ClockWait 策略的最长等待时间，默认5秒
+//
+// @Description: 设置 ClockWait 策略的最长等待时间
+// @param d 最长等待时间
+// @return Option
+func WithMaxClockWait(d time.Duration) Option {
+	return func(c *config) {
+		c.maxWait = d
+	}
+}
+
+// WithClock 设置时间源，默认 time.Now，主要用于测试
+//
+// @Description: 设置时间源
+// @param clock 时间源
+// @return Option
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// millis 当前时间相对纪元的毫秒数
+func (g *Generator) millis() int64 {
+	return g.clock().Sub(g.layout.Epoch).Milliseconds()
+}
+
+// tick 获取当前时间戳，早于上一次的时间戳 last 时按策略处理
+func (g *Generator) tick(last int64) (int64, error) {
+	now := g.millis()
+	if now < 0 {
+		return 0, fmt.Errorf("snow_node: clock is before epoch %v", g.layout.Epoch)
+	}
+	if now >= last {
+		return now, nil
+	}
+	drift := time.Duration(last-now) * time.Millisecond
+	switch g.policy {
+	case ClockLogical:
+		return last, nil
+	case ClockWait:
+		if drift <= g.maxWait {
+			time.Sleep(drift)
+			for now = g.millis(); now < last; now = g.millis() {
+				time.Sleep(time.Millisecond)
+			}
+			return now, nil
+		}
+	}
+	return 0, fmt.Errorf("%w by %v", ErrClockBackwards, drift)
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package snow_node
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLockFile 当前平台不支持 flock，FileAllocator 的所有操作返回 errors.ErrUnsupported
+func tryLockFile(f *os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+// unlockFile 释放文件锁
+func unlockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
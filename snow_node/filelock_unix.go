@@ -0,0 +1,25 @@
+//go:build unix
+
+package snow_node
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLockFile 以非阻塞方式对文件加排他锁(flock)，锁被其他进程持有时返回 false
+//
+// flock 随文件描述符关闭或进程退出由内核自动释放，不存在残留的锁
+func tryLockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile 释放文件锁
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
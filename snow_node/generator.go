@@ -0,0 +1,29 @@
+package snow_node
+
+import "strconv"
+
+// IDGenerator ID 生成器
+//
+// 雪花 ID、ULID、UUID、KSUID、NanoID 生成器都实现了该接口，便于业务按需替换 ID 格式
+type IDGenerator interface {
+	// NewID 生成一个字符串形式的 ID
+	NewID() (string, error)
+}
+
+var (
+	_ IDGenerator = (*Generator)(nil)
+	_ IDGenerator = (*ShardedGenerator)(nil)
+	_ IDGenerator = (*ULIDGenerator)(nil)
+	_ IDGenerator = (*UUIDGenerator)(nil)
+	_ IDGenerator = (*KSUIDGenerator)(nil)
+	_ IDGenerator = (*NanoIDGenerator)(nil)
+)
+
+// NewID 生成十进制雪花 ID
+func (g *Generator) NewID() (string, error) {
+	id, err := g.NextID()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
@@ -0,0 +1,67 @@
+package snow_node
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// IDInfo 雪花 ID 的组成部分
+type IDInfo struct {
+	ID       int64     `json:"id"`
+	Time     time.Time `json:"time"`     //生成时间，精确到毫秒
+	NodeID   int64     `json:"node_id"`  //节点 ID
+	Sequence int64     `json:"sequence"` //同一毫秒内的序号
+}
+
+// Parse 按默认布局解析雪花 ID
+//
+// @Description: 解析雪花 ID 的生成时间、节点 ID 和序号，自定义布局请使用 Layout.Parse
+// @param id 雪花 ID
+// @return IDInfo
+func Parse(id int64) IDInfo {
+	return DefaultLayout.Parse(id)
+}
+
+// ParseString 按默认布局解析十进制雪花 ID 字符串
+//
+// @Description: 解析 GetID 返回的十进制字符串
+// @param s 十进制雪花 ID
+// @return IDInfo
+// @return error 格式错误
+func ParseString(s string) (IDInfo, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 0 {
+		return IDInfo{}, fmt.Errorf("snow_node: invalid id %q", s)
+	}
+	return Parse(id), nil
+}
+
+// MinIDForTime 指定时间(毫秒)内可能生成的最小 ID
+//
+// @Description: 用于按时间范围查询以雪花 ID 为主键的表，早于纪元的时间返回0
+// @param t 时间
+// @return int64 最小 ID
+func MinIDForTime(t time.Time) int64 {
+	return DefaultLayout.MinIDForTime(t)
+}
+
+// MaxIDForTime 指定时间(毫秒)内可能生成的最大 ID
+//
+// @Description: 用于按时间范围查询以雪花 ID 为主键的表
+// @param t 时间
+// @return int64 最大 ID
+func MaxIDForTime(t time.Time) int64 {
+	return DefaultLayout.MaxIDForTime(t)
+}
+
+// IDRange 时间范围 [start, end] 对应的 ID 范围
+//
+// @Description: 返回的 min、max 可直接用于 id BETWEEN min AND max 查询
+// @param start 开始时间
+// @param end 结束时间
+// @return min 最小 ID
+// @return max 最大 ID
+func IDRange(start, end time.Time) (min, max int64) {
+	return DefaultLayout.IDRange(start, end)
+}
@@ -0,0 +1,107 @@
+package snow_node
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+const (
+	// ksuidEpoch KSUID 纪元 2014-05-13 16:53:20 UTC
+	ksuidEpoch = 1400000000
+	// ksuidAlphabet KSUID Base62 字母表
+	ksuidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// ksuidLength KSUID 字符串长度
+	ksuidLength = 27
+)
+
+// ErrInvalidKSUID KSUID 格式错误
+var ErrInvalidKSUID = errors.New("snow_node: invalid ksuid")
+
+// KSUID 160 位 KSUID：32 位秒级时间戳 + 128 位随机数
+type KSUID [20]byte
+
+// Time 生成时间
+func (k KSUID) Time() time.Time {
+	ts := int64(k[0])<<24 | int64(k[1])<<16 | int64(k[2])<<8 | int64(k[3])
+	return time.Unix(ts+ksuidEpoch, 0)
+}
+
+// String 27 位 Base62 字符串
+func (k KSUID) String() string {
+	n := new(big.Int).SetBytes(k[:])
+	base := big.NewInt(62)
+	mod := new(big.Int)
+	out := make([]byte, ksuidLength)
+	for i := ksuidLength - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = ksuidAlphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+// ParseKSUID 解析 KSUID 字符串
+//
+// @Description: 解析 27 位 Base62 KSUID
+// @param s KSUID 字符串
+// @return KSUID
+// @return error 格式错误时返回 ErrInvalidKSUID
+func ParseKSUID(s string) (KSUID, error) {
+	var k KSUID
+	if len(s) != ksuidLength {
+		return k, ErrInvalidKSUID
+	}
+	n := new(big.Int)
+	base := big.NewInt(62)
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(ksuidAlphabet, s[i])
+		if v < 0 {
+			return k, ErrInvalidKSUID
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(v)))
+	}
+	if n.BitLen() > 160 {
+		return k, ErrInvalidKSUID
+	}
+	n.FillBytes(k[:])
+	return k, nil
+}
+
+// ValidKSUID 判断是否为合法的 KSUID 字符串
+func ValidKSUID(s string) bool {
+	_, err := ParseKSUID(s)
+	return err == nil
+}
+
+// KSUIDGenerator KSUID 生成器
+type KSUIDGenerator struct {
+	nowFunc func() time.Time
+}
+
+// NewKSUIDGenerator 创建 KSUID 生成器
+func NewKSUIDGenerator() *KSUIDGenerator {
+	return &KSUIDGenerator{nowFunc: time.Now}
+}
+
+// Next 生成一个 KSUID
+func (g *KSUIDGenerator) Next() (KSUID, error) {
+	var k KSUID
+	ts := uint32(g.nowFunc().Unix() - ksuidEpoch)
+	k[0], k[1], k[2], k[3] = byte(ts>>24), byte(ts>>16), byte(ts>>8), byte(ts)
+	if err := utils.RandomBytes(k[4:]); err != nil {
+		return k, err
+	}
+	return k, nil
+}
+
+// NewID 生成 KSUID 字符串
+func (g *KSUIDGenerator) NewID() (string, error) {
+	k, err := g.Next()
+	if err != nil {
+		return "", err
+	}
+	return k.String(), nil
+}
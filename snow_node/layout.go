@@ -0,0 +1,108 @@
+package snow_node
+
+import (
+	"fmt"
+	"time"
+)
+
+// Layout 雪花 ID 位布局
+//
+// ID 由高到低依次为：1 位符号位(始终为0)、时间戳(毫秒，相对 Epoch)、节点 ID、序号，
+// 时间戳位数为 63 - NodeBits - StepBits
+type Layout struct {
+	Epoch    time.Time //纪元
+	NodeBits uint8     //节点 ID 位数
+	StepBits uint8     //序号位数
+}
+
+// DefaultLayout 默认布局，与 Twitter Snowflake 一致：纪元 2010-11-04 01:42:54.657 UTC，10 位节点、12 位序号
+var DefaultLayout = Layout{
+	Epoch:    time.UnixMilli(1288834974657),
+	NodeBits: 10,
+	StepBits: 12,
+}
+
+// validate 校验布局，时间戳至少保留 31 位(约 24 天)，纪元不能为零值或晚于当前时间
+func (l Layout) validate(now time.Time) error {
+	if l.Epoch.IsZero() {
+		return fmt.Errorf("snow_node: layout epoch must be set")
+	}
+	if l.Epoch.After(now) {
+		return fmt.Errorf("snow_node: layout epoch %s is in the future", l.Epoch.Format(time.RFC3339))
+	}
+	if l.NodeBits > 32 || l.StepBits > 32 {
+		return fmt.Errorf("snow_node: node bits and step bits must not exceed 32, got %d and %d", l.NodeBits, l.StepBits)
+	}
+	// 转为 int 再相加，避免 uint8 溢出
+	if bits := int(l.NodeBits) + int(l.StepBits); bits > 32 {
+		return fmt.Errorf("snow_node: node bits + step bits must not exceed 32, got %d", bits)
+	}
+	if l.StepBits == 0 {
+		return fmt.Errorf("snow_node: step bits must be positive")
+	}
+	return nil
+}
+
+// MaxNodeID 布局允许的最大节点 ID
+func (l Layout) MaxNodeID() int64 {
+	return -1 ^ (-1 << l.NodeBits)
+}
+
+// maxStep 布局允许的最大序号
+func (l Layout) maxStep() int64 {
+	return -1 ^ (-1 << l.StepBits)
+}
+
+// maxTime 布局允许的最大时间戳(相对纪元的毫秒数)
+func (l Layout) maxTime() int64 {
+	return -1 ^ (-1 << (63 - l.NodeBits - l.StepBits))
+}
+
+// timeShift 时间戳左移位数
+func (l Layout) timeShift() uint8 {
+	return l.NodeBits + l.StepBits
+}
+
+// compose 组合 ID
+func (l Layout) compose(ms, node, step int64) int64 {
+	return ms<<l.timeShift() | node<<l.StepBits | step
+}
+
+// Parse 按布局解析雪花 ID
+//
+// @Description: 解析雪花 ID 的生成时间、节点 ID 和序号
+// @param id 雪花 ID
+// @return IDInfo
+func (l Layout) Parse(id int64) IDInfo {
+	return IDInfo{
+		ID:       id,
+		Time:     l.Epoch.Add(time.Duration(id>>l.timeShift()) * time.Millisecond),
+		NodeID:   (id >> l.StepBits) & l.MaxNodeID(),
+		Sequence: id & l.maxStep(),
+	}
+}
+
+// MinIDForTime 按布局计算指定时间(毫秒)内可能生成的最小 ID，早于纪元时返回0
+func (l Layout) MinIDForTime(t time.Time) int64 {
+	ms := t.Sub(l.Epoch).Milliseconds()
+	if ms < 0 {
+		return 0
+	}
+	if ms > l.maxTime() {
+		ms = l.maxTime()
+	}
+	return ms << l.timeShift()
+}
+
+// MaxIDForTime 按布局计算指定时间(毫秒)内可能生成的最大 ID，早于纪元时返回0
+func (l Layout) MaxIDForTime(t time.Time) int64 {
+	if t.Before(l.Epoch) {
+		return 0
+	}
+	return l.MinIDForTime(t) | (1<<l.timeShift() - 1)
+}
+
+// IDRange 按布局计算时间范围 [start, end] 对应的 ID 范围
+func (l Layout) IDRange(start, end time.Time) (min, max int64) {
+	return l.MinIDForTime(start), l.MaxIDForTime(end)
+}
@@ -0,0 +1,66 @@
+package snow_node
+
+import (
+	"errors"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+const (
+	// NanoIDAlphabet NanoID 默认的 URL 安全字母表
+	NanoIDAlphabet = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// NanoIDSize NanoID 默认长度
+	NanoIDSize = 21
+)
+
+// NanoIDGenerator NanoID 生成器
+type NanoIDGenerator struct {
+	alphabet string
+	size     int
+	valid    [256]bool
+}
+
+// NewNanoIDGenerator 创建 NanoID 生成器
+//
+// @Description: 使用 crypto/rand 与掩码拒绝采样，保证每个字符等概率出现
+// @param alphabet 字母表，为空时使用 NanoIDAlphabet，长度 2~256 且不能有重复字符
+// @param size ID 长度，小于等于0时使用 NanoIDSize
+// @return *NanoIDGenerator
+// @return error 字母表不合法
+func NewNanoIDGenerator(alphabet string, size int) (*NanoIDGenerator, error) {
+	if alphabet == "" {
+		alphabet = NanoIDAlphabet
+	}
+	if size <= 0 {
+		size = NanoIDSize
+	}
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return nil, errors.New("snow_node: nanoid alphabet must contain 2 to 256 characters")
+	}
+	g := &NanoIDGenerator{alphabet: alphabet, size: size}
+	for i := 0; i < len(alphabet); i++ {
+		if g.valid[alphabet[i]] {
+			return nil, errors.New("snow_node: nanoid alphabet contains duplicate characters")
+		}
+		g.valid[alphabet[i]] = true
+	}
+	return g, nil
+}
+
+// NewID 生成 NanoID
+func (g *NanoIDGenerator) NewID() (string, error) {
+	return utils.RandomString(g.alphabet, g.size)
+}
+
+// Valid 判断字符串是否为该生成器可能生成的 NanoID
+func (g *NanoIDGenerator) Valid(s string) bool {
+	if len(s) != g.size {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !g.valid[s[i]] {
+			return false
+		}
+	}
+	return true
+}
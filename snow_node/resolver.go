@@ -0,0 +1,167 @@
+package snow_node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NodeIDResolver 节点 ID 解析器，找不到时返回 ErrNoNodeID
+type NodeIDResolver func() (int64, error)
+
+// Option 生成器可选配置
+type Option func(c *config)
+
+// config 生成器配置
+type config struct {
+	resolvers []NodeIDResolver
+	allocator NodeIDAllocator //通过分配器获得节点 ID 时的分配器与租约
+	lease     *Lease
+	ttl       time.Duration
+
+	layout  Layout
+	policy  ClockPolicy
+	maxWait time.Duration
+	clock   func() time.Time
+}
+
+// resolve 按顺序执行解析器
+func (c *config) resolve() (int64, error) {
+	if len(c.resolvers) == 0 {
+		return 0, errors.New("snow_node: AutoNodeID requires at least one resolver option")
+	}
+	for _, r := range c.resolvers {
+		id, err := r()
+		if errors.Is(err, ErrNoNodeID) {
+			continue
+		}
+		return id, err
+	}
+	return 0, ErrNoNodeID
+}
+
+// WithResolver 添加自定义节点 ID 解析器
+//
+// @Description: 添加自定义节点 ID 解析器
+// @param r 解析器
+// @return Option
+func WithResolver(r NodeIDResolver) Option {
+	return func(c *config) {
+		c.resolvers = append(c.resolvers, r)
+	}
+}
+
+// WithAllocator 通过分配器租用节点 ID
+//
+// @Description: 租用成功后生成器在后台定期续约，租约失效后 NextID 返回 ErrLeaseLost，
+// 调用 Generator.Close 释放租约
+// @param alloc 分配器，如 NewFileAllocator
+// @param owner 持有者标识
+// @param ttl 租约时长，必须大于0，否则 New 返回 ErrInvalidTTL
+// @return Option
+func WithAllocator(alloc NodeIDAllocator, owner string, ttl time.Duration) Option {
+	return func(c *config) {
+		c.resolvers = append(c.resolvers, func() (int64, error) {
+			if ttl <= 0 {
+				return 0, ErrInvalidTTL
+			}
+			lease, err := alloc.Acquire(context.Background(), owner, c.layout.MaxNodeID(), ttl)
+			if err != nil {
+				return 0, err
+			}
+			c.allocator, c.lease, c.ttl = alloc, lease, ttl
+			return lease.NodeID, nil
+		})
+	}
+}
+
+// WithEnv 从环境变量读取节点 ID
+//
+// @Description: 环境变量未设置时尝试下一个解析器，值不是合法整数时返回错误
+// @param name 环境变量名，如 EnvNodeID
+// @return Option
+func WithEnv(name string) Option {
+	return WithResolver(func() (int64, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok || strings.TrimSpace(v) == "" {
+			return 0, ErrNoNodeID
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("snow_node: invalid node id in $%s: %w", name, err)
+		}
+		return id, nil
+	})
+}
+
+// WithHostIP 由本机 IPv4 地址确定节点 ID
+//
+// @Description: 取第一个非回环 IPv4 地址的低位作为节点 ID，默认布局下同一网段(/22)内的主机不会冲突
+// @return Option
+func WithHostIP() Option {
+	return func(c *config) {
+		c.resolvers = append(c.resolvers, func() (int64, error) {
+			addrs, err := net.InterfaceAddrs()
+			if err != nil {
+				return 0, fmt.Errorf("snow_node: list interface addresses: %w", err)
+			}
+			for _, addr := range addrs {
+				ipNet, ok := addr.(*net.IPNet)
+				if !ok || ipNet.IP.IsLoopback() {
+					continue
+				}
+				if ip := ipNet.IP.To4(); ip != nil {
+					return NodeIDFromIP(ip) & c.layout.MaxNodeID(), nil
+				}
+			}
+			return 0, ErrNoNodeID
+		})
+	}
+}
+
+// WithLeaseFile 从租约文件读取节点 ID
+//
+// @Description: 文件内容为十进制节点 ID，通常由部署系统写入；文件不存在时尝试下一个解析器
+// @param path 文件路径
+// @return Option
+func WithLeaseFile(path string) Option {
+	return WithResolver(func() (int64, error) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, ErrNoNodeID
+		}
+		if err != nil {
+			return 0, fmt.Errorf("snow_node: read lease file: %w", err)
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("snow_node: invalid node id in %s: %w", path, err)
+		}
+		return id, nil
+	})
+}
+
+// NodeIDFromIP 由 IPv4 地址计算节点 ID
+//
+// @Description: 取地址低 16 位作为节点 ID，调用方需按布局截取，如 NodeIDFromIP(ip) & layout.MaxNodeID()
+// @param ip IPv4 地址
+// @return int64 节点 ID
+func NodeIDFromIP(ip net.IP) int64 {
+	ip = ip.To4()
+	if ip == nil {
+		return 0
+	}
+	return int64(ip[2])<<8 | int64(ip[3])
+}
+
+// releaseLease 创建生成器失败时释放已租用的节点 ID
+func (c *config) releaseLease() {
+	if c.lease != nil {
+		_ = c.allocator.Release(context.Background(), c.lease)
+	}
+}
@@ -1,22 +1,341 @@
 package snow_node
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/bwmarrin/snowflake"
-	"math/rand"
+	"log"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// AutoNodeID 由 Option 中的解析器自动确定节点 ID
+	AutoNodeID int64 = -1
+	// EnvNodeID 默认读取节点 ID 的环境变量
+	EnvNodeID = "SNOW_NODE_ID"
+	// MaxNodeID 默认布局(10 位节点)下节点 ID 最大值，其他布局请使用 Layout.MaxNodeID()
+	MaxNodeID int64 = 1<<10 - 1
 )
 
 var (
-	snowNode *snowflake.Node
+	// ErrInvalidNodeID 节点 ID 超出布局允许的范围
+	ErrInvalidNodeID = errors.New("snow_node: node id out of range")
+	// ErrClockBackwards 系统时钟回拨
+	ErrClockBackwards = errors.New("snow_node: clock moved backwards")
+	// ErrTimeOverflow 时间戳超出布局允许的范围
+	ErrTimeOverflow = errors.New("snow_node: timestamp overflow")
+	// ErrNoNodeID 解析器未找到节点 ID，继续尝试下一个解析器
+	ErrNoNodeID = errors.New("snow_node: node id not found")
 )
 
-func init() {
-	node := int64(rand.Intn(1000))
-	snowNode, _ = snowflake.NewNode(node)
+// Generator 雪花 ID 生成器
+type Generator struct {
+	nodeID  int64
+	layout  Layout
+	policy  ClockPolicy
+	maxWait time.Duration
+	clock   func() time.Time
+
+	// state 上一次分配的时间戳(相对纪元的毫秒数)<<StepBits | 序号，通过 CAS 无锁更新
+	state atomic.Int64
+
+	allocator NodeIDAllocator //租用节点 ID 时的分配器与租约
+	lease     *Lease
+	stop      context.CancelFunc
+	lost      atomic.Bool
+}
+
+// New 创建雪花 ID 生成器
+//
+// @Description: nodeID 为 AutoNodeID 时按 opts 中解析器的顺序确定节点 ID，
+// 解析器返回 ErrNoNodeID 时尝试下一个，返回其他错误时直接返回
+// @param nodeID 节点 ID，取值 0~Layout.MaxNodeID() 或 AutoNodeID
+// @param opts 可选配置，如 WithEnv、WithHostIP、WithLeaseFile、WithLayout、WithClockPolicy
+// @return *Generator
+// @return error 配置错误
+func New(nodeID int64, opts ...Option) (*Generator, error) {
+	cfg := &config{
+		layout:  DefaultLayout,
+		policy:  ClockWait,
+		maxWait: defaultMaxClockWait,
+		clock:   time.Now,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if err := cfg.layout.validate(cfg.clock()); err != nil {
+		return nil, err
+	}
+	if nodeID == AutoNodeID {
+		id, err := cfg.resolve()
+		if err != nil {
+			return nil, err
+		}
+		nodeID = id
+	}
+	if nodeID < 0 || nodeID > cfg.layout.MaxNodeID() {
+		cfg.releaseLease()
+		return nil, fmt.Errorf("%w: %d not in [0, %d]", ErrInvalidNodeID, nodeID, cfg.layout.MaxNodeID())
+	}
+	g := &Generator{
+		nodeID:  nodeID,
+		layout:  cfg.layout,
+		policy:  cfg.policy,
+		maxWait: cfg.maxWait,
+		clock:   cfg.clock,
+	}
+	g.state.Store(-1 << cfg.layout.StepBits)
+	if cfg.lease != nil {
+		g.keepAlive(cfg)
+	}
+	return g, nil
+}
+
+// keepAlive 后台续约节点 ID 租约
+func (g *Generator) keepAlive(cfg *config) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.allocator, g.lease, g.stop = cfg.allocator, cfg.lease, cancel
+	go KeepAlive(ctx, cfg.allocator, cfg.lease, cfg.ttl, func(err error) {
+		g.lost.Store(true)
+	})
+}
+
+// Close 停止续约并释放节点 ID 租约，未使用分配器时无操作
+//
+// @Description: 关闭后不应再生成 ID
+// @return error
+func (g *Generator) Close() error {
+	if g.lease == nil {
+		return nil
+	}
+	g.stop()
+	g.lost.Store(true)
+	return g.allocator.Release(context.Background(), g.lease)
+}
+
+// NodeID 获取节点 ID
+func (g *Generator) NodeID() int64 {
+	return g.nodeID
+}
+
+// Layout 获取位布局，可用于解析该生成器生成的 ID
+func (g *Generator) Layout() Layout {
+	return g.layout
+}
+
+// NextID 生成一个雪花 ID
+//
+// @Description: 生成一个雪花 ID，时钟回拨时按 ClockPolicy 处理，并发调用无锁
+// @return int64 雪花 ID
+// @return error 节点 ID 租约失效时返回 ErrLeaseLost，ClockError 策略下时钟回拨返回 ErrClockBackwards
+func (g *Generator) NextID() (int64, error) {
+	ms, step, _, err := g.reserve(1)
+	if err != nil {
+		return 0, err
+	}
+	return g.layout.compose(ms, g.nodeID, step), nil
+}
+
+// NextIDs 批量生成 n 个递增的雪花 ID
+//
+// @Description: 每次 CAS 预留当前毫秒内剩余的一段序号，比循环调用 NextID 竞争更少
+// @param n 数量，n <= 0 时返回空切片
+// @return []int64 雪花 ID
+// @return error 同 NextID
+func (g *Generator) NextIDs(n int) ([]int64, error) {
+	if n <= 0 {
+		return []int64{}, nil
+	}
+	ids := make([]int64, 0, n)
+	for len(ids) < n {
+		ms, first, count, err := g.reserve(int64(n - len(ids)))
+		if err != nil {
+			return nil, err
+		}
+		for step := first; step < first+count; step++ {
+			ids = append(ids, g.layout.compose(ms, g.nodeID, step))
+		}
+	}
+	return ids, nil
+}
+
+// reserve 预留最多 n 个序号，返回时间戳、起始序号和实际预留数量
+func (g *Generator) reserve(n int64) (ms, first, count int64, err error) {
+	if g.lost.Load() {
+		return 0, 0, 0, ErrLeaseLost
+	}
+	maxStep := g.layout.maxStep()
+	for {
+		old := g.state.Load()
+		last, step := old>>g.layout.StepBits, old&maxStep
+		now, err := g.tick(last)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		switch {
+		case now > last:
+			first = 0
+		case step < maxStep:
+			first = step + 1
+		case g.policy == ClockLogical:
+			now, first = last+1, 0
+		default:
+			// 当前毫秒的序号已用完，等待下一毫秒
+			runtime.Gosched()
+			continue
+		}
+		if now > g.layout.maxTime() {
+			return 0, 0, 0, ErrTimeOverflow
+		}
+		count = min(n, maxStep-first+1)
+		if g.state.CompareAndSwap(old, now<<g.layout.StepBits|(first+count-1)) {
+			return now, first, count, nil
+		}
+	}
 }
 
+var (
+	defaultMu   sync.Mutex
+	defaultGen  *Generator
+	fallbackGen *Generator //无法确定节点 ID 时 GetID/GetIDs 使用的随机节点生成器
+)
+
+// Init 初始化包级默认生成器
+//
+// @Description: 初始化 GetID 使用的默认生成器，建议在程序启动时调用以便处理配置错误
+// @param nodeID 节点 ID，取值 0~Layout.MaxNodeID() 或 AutoNodeID
+// @param opts 可选配置
+// @return error 配置错误
+func Init(nodeID int64, opts ...Option) error {
+	g, err := New(nodeID, opts...)
+	if err != nil {
+		return err
+	}
+	SetDefault(g)
+	return nil
+}
+
+// SetDefault 设置包级默认生成器
+func SetDefault(g *Generator) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultGen = g
+}
+
+// Default 获取包级默认生成器
+//
+// @Description: 未调用 Init 或 SetDefault 时，依次从环境变量 SNOW_NODE_ID 和本机 IP 确定节点 ID
+// @return *Generator
+// @return error 配置错误
+func Default() (*Generator, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultGen == nil {
+		g, err := New(AutoNodeID, WithEnv(EnvNodeID), WithHostIP())
+		if err != nil {
+			return nil, err
+		}
+		defaultGen = g
+	}
+	return defaultGen, nil
+}
+
+// fallback 获取 GetID/GetIDs 使用的生成器
+//
+// 默认生成器无法确定节点 ID 时，与旧版本一样改用随机节点 ID 的后备生成器并在首次使用时记录警告，
+// 后备生成器与其替代的默认生成器使用相同的 DefaultLayout；已有默认生成器时始终返回它，不会改用随机节点
+func fallback() *Generator {
+	g, err := Default()
+	if err == nil {
+		return g
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultGen != nil {
+		return defaultGen
+	}
+	if fallbackGen == nil {
+		nodeID := rand.Int64N(DefaultLayout.MaxNodeID() + 1)
+		fallbackGen, _ = New(nodeID, WithLayout(DefaultLayout), WithClockPolicy(ClockLogical))
+		log.Printf("[snow_node] cannot resolve node id: %v, falling back to random node id %d", err, nodeID)
+	}
+	return fallbackGen
+}
+
+// GetIDE 使用默认生成器生成十进制雪花 ID
+//
+// @Description: 默认生成器无法确定节点 ID 或生成失败时返回错误
+// @return string 十进制雪花 ID
+// @return error 配置错误或 NextID 的错误
+func GetIDE() (string, error) {
+	g, err := Default()
+	if err != nil {
+		return "", err
+	}
+	id, err := g.NextID()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// GetIDsE 使用默认生成器批量生成十进制雪花 ID
+//
+// @Description: 默认生成器无法确定节点 ID 或生成失败时返回错误
+// @param n 数量，n <= 0 时返回空切片
+// @return []string 十进制雪花 ID
+// @return error 配置错误或 NextIDs 的错误
+func GetIDsE(n int) ([]string, error) {
+	g, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := g.NextIDs(n)
+	if err != nil {
+		return nil, err
+	}
+	return formatIDs(ids), nil
+}
+
+// GetID 使用默认生成器生成十进制雪花 ID
+//
+// @Description: 无法确定节点 ID 时记录警告并改用随机节点 ID 的后备生成器；
+// 生成失败(如租约失效、ClockError 策略下时钟回拨)时记录日志并返回空串，不会 panic；需要处理错误时请使用 GetIDE
+// @return string 十进制雪花 ID
 func GetID() string {
-	// Generate a snowflake ID.
-	id := snowNode.Generate()
-	return fmt.Sprintf("%d", id)
+	id, err := fallback().NextID()
+	if err != nil {
+		log.Printf("[snow_node] generate id failed: %v", err)
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// GetIDs 使用默认生成器批量生成十进制雪花 ID
+//
+// @Description: 无法确定节点 ID 时记录警告并改用随机节点 ID 的后备生成器；
+// 生成失败时记录日志并返回 nil，不会 panic；需要处理错误时请使用 GetIDsE
+// @param n 数量
+// @return []string 十进制雪花 ID
+func GetIDs(n int) []string {
+	ids, err := fallback().NextIDs(n)
+	if err != nil {
+		log.Printf("[snow_node] generate ids failed: %v", err)
+		return nil
+	}
+	return formatIDs(ids)
+}
+
+// formatIDs 将雪花 ID 格式化为十进制字符串
+func formatIDs(ids []int64) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = strconv.FormatInt(id, 10)
+	}
+	return result
 }
@@ -0,0 +1,166 @@
+package snow_node
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+// crockford Crockford Base32 字母表
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	// ErrInvalidULID ULID 格式错误
+	ErrInvalidULID = errors.New("snow_node: invalid ulid")
+	// ErrULIDOverflow 同一毫秒内生成的 ULID 过多，随机部分溢出
+	ErrULIDOverflow = errors.New("snow_node: ulid entropy overflow")
+)
+
+// crockfordIndex 字符到数值的映射，不区分大小写，I L 视为 1，O 视为 0
+var crockfordIndex = func() [256]int8 {
+	var idx [256]int8
+	for i := range idx {
+		idx[i] = -1
+	}
+	for i, c := range crockford {
+		idx[c] = int8(i)
+		idx[strings.ToLower(string(c))[0]] = int8(i)
+	}
+	for _, c := range "iIlL" {
+		idx[c] = 1
+	}
+	for _, c := range "oO" {
+		idx[c] = 0
+	}
+	return idx
+}()
+
+// ULID 128 位 ULID：48 位毫秒时间戳 + 80 位随机数
+type ULID [16]byte
+
+// Time 生成时间
+func (u ULID) Time() time.Time {
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	return time.UnixMilli(ms)
+}
+
+// String 26 位 Crockford Base32 字符串
+func (u ULID) String() string {
+	var out [26]byte
+	// 128 位按 5 位一组编码，首字符只使用高 3 位(共130位)
+	var acc uint64
+	bits := 2 // 头部补2位0，凑成130位
+	pos := 0
+	for _, b := range u {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[pos] = crockford[(acc>>uint(bits))&31]
+			pos++
+		}
+	}
+	return string(out[:])
+}
+
+// ParseULID 解析 ULID 字符串
+//
+// @Description: 解析 26 位 Crockford Base32 ULID，不区分大小写
+// @param s ULID 字符串
+// @return ULID
+// @return error 格式错误时返回 ErrInvalidULID
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 {
+		return u, ErrInvalidULID
+	}
+	if crockfordIndex[s[0]] > 7 || crockfordIndex[s[0]] < 0 {
+		return u, ErrInvalidULID // 首字符超过 7 会溢出 128 位
+	}
+	var acc uint64
+	bits := -2 // 丢弃头部补的2位0
+	pos := 0
+	for i := 0; i < len(s); i++ {
+		v := crockfordIndex[s[i]]
+		if v < 0 {
+			return u, ErrInvalidULID
+		}
+		acc = acc<<5 | uint64(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			u[pos] = byte(acc >> uint(bits))
+			pos++
+		}
+	}
+	return u, nil
+}
+
+// ValidULID 判断是否为合法的 ULID 字符串
+func ValidULID(s string) bool {
+	_, err := ParseULID(s)
+	return err == nil
+}
+
+// ULIDGenerator 单调递增的 ULID 生成器
+//
+// 同一毫秒内的 ULID 在上一个随机数基础上加1，保证字典序递增
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	last    ULID
+	lastMs  int64
+	nowFunc func() time.Time
+}
+
+// NewULIDGenerator 创建 ULID 生成器
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{nowFunc: time.Now}
+}
+
+// Next 生成一个 ULID
+//
+// @Description: 同一毫秒内随机部分溢出时返回 ErrULIDOverflow
+// @return ULID
+// @return error
+func (g *ULIDGenerator) Next() (ULID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ms := g.nowFunc().UnixMilli()
+	if ms <= g.lastMs {
+		// 同一毫秒或时钟回拨：沿用上一个时间戳并递增随机部分
+		u := g.last
+		for i := 15; i >= 6; i-- {
+			u[i]++
+			if u[i] != 0 {
+				g.last = u
+				return u, nil
+			}
+		}
+		return ULID{}, ErrULIDOverflow
+	}
+	if ms >= 1<<48 {
+		return ULID{}, fmt.Errorf("snow_node: ulid timestamp overflow")
+	}
+	var u ULID
+	for i := 0; i < 6; i++ {
+		u[i] = byte(ms >> uint(40-8*i))
+	}
+	if err := utils.RandomBytes(u[6:]); err != nil {
+		return ULID{}, err
+	}
+	g.last, g.lastMs = u, ms
+	return u, nil
+}
+
+// NewID 生成 ULID 字符串
+func (g *ULIDGenerator) NewID() (string, error) {
+	u, err := g.Next()
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,158 @@
+package snow_node
+
+import (
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xierui921326/toolkit/utils"
+)
+
+// ErrInvalidUUID UUID 格式错误
+var ErrInvalidUUID = errors.New("snow_node: invalid uuid")
+
+// UUID 128 位 UUID
+type UUID [16]byte
+
+// Version UUID 版本号
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time UUIDv7 的生成时间，其他版本返回零值
+func (u UUID) Time() time.Time {
+	if u.Version() != 7 {
+		return time.Time{}
+	}
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	return time.UnixMilli(ms)
+}
+
+// String 标准 8-4-4-4-12 格式的小写字符串
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// ParseUUID 解析 UUID 字符串
+//
+// @Description: 解析标准 8-4-4-4-12 格式的 UUID，不区分大小写，要求 RFC 9562 变体
+// @param s UUID 字符串
+// @return UUID
+// @return error 格式错误时返回 ErrInvalidUUID
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, ErrInvalidUUID
+	}
+	src := []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+	if _, err := hex.Decode(u[:], src); err != nil {
+		return u, ErrInvalidUUID
+	}
+	if u[8]&0xc0 != 0x80 {
+		return u, ErrInvalidUUID
+	}
+	return u, nil
+}
+
+// ValidUUID 判断是否为合法的 UUID 字符串
+func ValidUUID(s string) bool {
+	_, err := ParseUUID(s)
+	return err == nil
+}
+
+// NewUUIDv4 生成随机 UUIDv4
+//
+// @Description: 生成随机 UUIDv4
+// @return UUID
+// @return error
+func NewUUIDv4() (UUID, error) {
+	var u UUID
+	if err := utils.RandomBytes(u[:]); err != nil {
+		return u, err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// UUIDGenerator UUID 生成器
+type UUIDGenerator struct {
+	version int
+	mu      sync.Mutex
+	lastMs  int64
+	counter uint16 //UUIDv7 的 12 位计数器(rand_a)
+	nowFunc func() time.Time
+}
+
+// NewUUIDv4Generator 创建 UUIDv4 生成器
+func NewUUIDv4Generator() *UUIDGenerator {
+	return &UUIDGenerator{version: 4, nowFunc: time.Now}
+}
+
+// NewUUIDv7Generator 创建单调递增的 UUIDv7 生成器
+//
+// @Description: 同一毫秒内以 12 位 rand_a 作为计数器递增，计数器用尽时时间戳加1毫秒(RFC 9562 方法1)
+// @return *UUIDGenerator
+func NewUUIDv7Generator() *UUIDGenerator {
+	return &UUIDGenerator{version: 7, nowFunc: time.Now}
+}
+
+// Next 生成一个 UUID
+func (g *UUIDGenerator) Next() (UUID, error) {
+	if g.version == 4 {
+		return NewUUIDv4()
+	}
+	return g.nextV7()
+}
+
+// nextV7 生成 UUIDv7
+func (g *UUIDGenerator) nextV7() (UUID, error) {
+	var u UUID
+	if err := utils.RandomBytes(u[6:]); err != nil {
+		return u, err
+	}
+
+	g.mu.Lock()
+	ms := g.nowFunc().UnixMilli()
+	if ms > g.lastMs {
+		// 新的毫秒：计数器从随机值开始，保留一半空间用于递增
+		g.lastMs = ms
+		g.counter = (uint16(u[6])<<8 | uint16(u[7])) & 0x7ff
+	} else {
+		g.counter++
+		if g.counter > 0xfff {
+			g.lastMs++
+			g.counter = 0
+		}
+	}
+	ms, counter := g.lastMs, g.counter
+	g.mu.Unlock()
+
+	for i := 0; i < 6; i++ {
+		u[i] = byte(ms >> uint(40-8*i))
+	}
+	u[6] = 0x70 | byte(counter>>8)
+	u[7] = byte(counter)
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// NewID 生成 UUID 字符串
+func (g *UUIDGenerator) NewID() (string, error) {
+	u, err := g.Next()
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,322 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xierui921326/toolkit/encrypt"
+)
+
+// 测试本地密钥环的信封加密、密钥轮换与更换主密钥
+func TestEncryptKeyring(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys", "keyring.json")
+	master := bytes.Repeat([]byte{1}, 32)
+	kr, err := encrypt.OpenKeyring(path, master)
+	if err != nil {
+		t.Fatalf("OpenKeyring failed: %v", err)
+	}
+	first := kr.PrimaryKeyID()
+
+	sealed, err := encrypt.Seal(ctx, kr, []byte("card 4111"), []byte("user:1"))
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("card 4111")) || !bytes.Contains(sealed, []byte(first)) {
+		t.Errorf("Expected ciphertext to hide plaintext and record key id")
+	}
+	if _, err := encrypt.Open(ctx, kr, sealed, []byte("user:2")); err == nil {
+		t.Errorf("Expected open with different aad to fail")
+	}
+
+	second, err := kr.Rotate()
+	if err != nil || second == first {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if plain, err := encrypt.Open(ctx, kr, sealed, []byte("user:1")); err != nil || string(plain) != "card 4111" {
+		t.Errorf("Expected old ciphertext to open after rotation, got %q (%v)", plain, err)
+	}
+	rewrapped, err := encrypt.Rewrap(ctx, kr, sealed)
+	if err != nil || !bytes.Contains(rewrapped, []byte(second)) {
+		t.Fatalf("Rewrap failed: %v", err)
+	}
+	if err := kr.Remove(second); err == nil {
+		t.Errorf("Expected error removing primary key")
+	}
+	if err := kr.Remove(first); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := encrypt.Open(ctx, kr, sealed, []byte("user:1")); !errors.Is(err, encrypt.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound for removed key, got %v", err)
+	}
+
+	newMaster := bytes.Repeat([]byte{2}, 32)
+	if err := kr.ChangeMasterKey(newMaster); err != nil {
+		t.Fatalf("ChangeMasterKey failed: %v", err)
+	}
+	if _, err := encrypt.OpenKeyring(path, master); !errors.Is(err, encrypt.ErrWrongMasterKey) {
+		t.Errorf("Expected ErrWrongMasterKey, got %v", err)
+	}
+	reopened, err := encrypt.OpenKeyring(path, newMaster)
+	if err != nil || reopened.PrimaryKeyID() != second || len(reopened.KeyIDs()) != 1 {
+		t.Fatalf("Expected reopened keyring with primary %s, got %v", second, err)
+	}
+	if plain, err := encrypt.Open(ctx, reopened, rewrapped, []byte("user:1")); err != nil || string(plain) != "card 4111" {
+		t.Errorf("Expected rewrapped ciphertext to open, got %q (%v)", plain, err)
+	}
+}
+
+// 测试自描述信封的各算法、文本格式与旧版密文导入
+func TestEncryptEnvelope(t *testing.T) {
+	keys := encrypt.StaticKeys{
+		"k128": bytes.Repeat([]byte{7}, 16),
+		"k256": bytes.Repeat([]byte{9}, 32),
+	}
+	algs := []encrypt.CipherAlgorithm{encrypt.AlgAesGcm, encrypt.AlgAesCbcPKCS7, encrypt.AlgAesCbcZero, encrypt.AlgAesEcbPKCS7, encrypt.AlgAesEcbZero}
+	for _, alg := range algs {
+		for kid, key := range keys {
+			e, err := encrypt.EncryptEnvelope(alg, kid, key, []byte("hello envelope"))
+			if err != nil {
+				t.Fatalf("%v/%s: EncryptEnvelope failed: %v", alg, kid, err)
+			}
+			bin, _ := e.MarshalBinary()
+			text, _ := e.MarshalText()
+			for _, data := range [][]byte{bin, text} {
+				plain, err := encrypt.Decrypt(data, keys)
+				if err != nil || string(plain) != "hello envelope" {
+					t.Errorf("%v/%s: Expected round trip, got %q (%v)", alg, kid, plain, err)
+				}
+			}
+		}
+	}
+	text, err := mustEnvelope(t, encrypt.AlgAesGcm).MarshalText()
+	if err != nil || !strings.HasPrefix(string(text), "$tke$1$AES-GCM$k256$") {
+		t.Errorf("Unexpected text envelope %s (%v)", text, err)
+	}
+	var parsed encrypt.Envelope
+	if err := parsed.UnmarshalText(text); err != nil || parsed.KeyID != "k256" {
+		t.Errorf("Expected text envelope to unmarshal, got %+v (%v)", parsed, err)
+	}
+	if _, err := (&encrypt.Envelope{Algorithm: 99}).MarshalText(); !errors.Is(err, encrypt.ErrUnsupportedEnvelope) {
+		t.Errorf("Expected MarshalText to report ErrUnsupportedEnvelope, got %v", err)
+	}
+
+	e, _ := encrypt.EncryptEnvelope(encrypt.AlgAesGcm, "k256", keys["k256"], []byte("bound"), []byte("order:1"))
+	bin, _ := e.MarshalBinary()
+	if _, err := encrypt.Decrypt(bin, keys, []byte("order:2")); err == nil {
+		t.Errorf("Expected decrypt with wrong aad to fail")
+	}
+	// 信封头部受认证：改写密钥 ID 后即使指向同一密钥也无法解密
+	forged := *e
+	forged.KeyID = "alias"
+	forgedBin, _ := forged.MarshalBinary()
+	aliased := encrypt.StaticKeys{"alias": keys["k256"]}
+	if _, err := encrypt.Decrypt(forgedBin, aliased, []byte("order:1")); err == nil {
+		t.Errorf("Expected decrypt with a rewritten key id to fail")
+	}
+	if _, err := encrypt.EncryptEnvelope(encrypt.AlgAesCbcPKCS7, "k256", keys["k256"], []byte("x"), []byte("aad")); err == nil {
+		t.Errorf("Expected CBC with aad to fail")
+	}
+	if _, err := encrypt.Decrypt(bin, encrypt.StaticKeys{}); !errors.Is(err, encrypt.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if _, err := encrypt.Decrypt(bin, nil); err == nil {
+		t.Errorf("Expected error for nil key provider")
+	}
+	if _, err := encrypt.Decrypt([]byte("tke\x09\x01"), keys); !errors.Is(err, encrypt.ErrUnsupportedEnvelope) {
+		t.Errorf("Expected ErrUnsupportedEnvelope, got %v", err)
+	}
+	if _, err := encrypt.Decrypt([]byte("tke\x01\x01"), keys); !errors.Is(err, encrypt.ErrMalformedCiphertext) {
+		t.Errorf("Expected ErrMalformedCiphertext, got %v", err)
+	}
+
+	ctx := context.Background()
+	kr, _ := encrypt.OpenKeyring(filepath.Join(t.TempDir(), "keyring.json"), bytes.Repeat([]byte{3}, 32))
+	sealed, _ := encrypt.Seal(ctx, kr, []byte("sealed"), nil)
+	if plain, err := encrypt.Decrypt(sealed, kr); err != nil || string(plain) != "sealed" || !bytes.HasPrefix(sealed, []byte("tke")) {
+		t.Errorf("Expected sealed envelope to decrypt, got %q (%v)", plain, err)
+	}
+	// 密钥环只解开数据密钥，不能解密直接以密钥加密的信封
+	if _, err := encrypt.Decrypt(bin, kr); err == nil {
+		t.Errorf("Expected keyring to reject an envelope without a wrapped key")
+	}
+}
+
+// 测试导入本包旧版 API 输出的密文
+func TestEncryptImportLegacy(t *testing.T) {
+	key := "0123456789abcdef"
+	iv := "fedcba9876543210"
+	keys := encrypt.StaticKeys{"legacy": []byte(key)}
+
+	gcm := &encrypt.AesGcm{Key: []byte(key)}
+	gcmOut, _ := gcm.Encrypt("legacy gcm")
+	cbcOut, _ := encrypt.NewAesCbc(encrypt.AesTypeCBCPKCS7Padding, key, iv).AesCbcEncrypt([]byte("legacy cbc"))
+	cbcZeroOut, _ := encrypt.NewAesCbc(encrypt.AesTypeCBCZeroPadding, key, iv).AesCbcEncrypt([]byte("legacy cbc zero"))
+	ecbOut, _ := encrypt.NewAesEcb(encrypt.AesTypeCBCPKCS7Padding, key).AesEcbEncrypt([]byte("legacy ecb"))
+
+	cases := []struct {
+		alg        encrypt.CipherAlgorithm
+		ciphertext string
+		iv         []byte
+		want       string
+	}{
+		{encrypt.AlgAesGcm, gcmOut, nil, "legacy gcm"},
+		{encrypt.AlgAesCbcPKCS7, cbcOut, []byte(iv), "legacy cbc"},
+		{encrypt.AlgAesCbcZero, cbcZeroOut, []byte(iv), "legacy cbc zero"},
+		{encrypt.AlgAesEcbPKCS7, ecbOut, nil, "legacy ecb"},
+	}
+	for _, c := range cases {
+		e, err := encrypt.ImportLegacy(c.alg, "legacy", []byte(key), c.ciphertext, c.iv)
+		if err != nil {
+			t.Fatalf("%v: ImportLegacy failed: %v", c.alg, err)
+		}
+		bin, _ := e.MarshalBinary()
+		if plain, err := encrypt.Decrypt(bin, keys); err != nil || string(plain) != c.want {
+			t.Errorf("%v: Expected %q, got %q (%v)", c.alg, c.want, plain, err)
+		}
+	}
+
+	//旧版 GCM 原始密文不会被误当作信封解析
+	raw, _ := base64.StdEncoding.DecodeString(gcmOut)
+	raw[0] = 1
+	if _, err := encrypt.Decrypt(raw, keys); !errors.Is(err, encrypt.ErrUnsupportedEnvelope) {
+		t.Errorf("Expected raw legacy ciphertext to be rejected, got %v", err)
+	}
+	if _, err := encrypt.ImportLegacy(encrypt.AlgAesCbcPKCS7, "legacy", []byte(key), cbcOut, nil); err == nil {
+		t.Errorf("Expected CBC import without iv to fail")
+	}
+	if _, err := encrypt.ImportLegacy(encrypt.AlgAesGcm, "legacy", []byte("fedcba9876543210"), gcmOut, nil); err == nil {
+		t.Errorf("Expected GCM import with a wrong key to fail")
+	}
+}
+
+// mustEnvelope 使用固定密钥生成信封
+func mustEnvelope(t *testing.T, alg encrypt.CipherAlgorithm) *encrypt.Envelope {
+	t.Helper()
+	e, err := encrypt.EncryptEnvelope(alg, "k256", bytes.Repeat([]byte{9}, 32), []byte("x"))
+	if err != nil {
+		t.Fatalf("EncryptEnvelope failed: %v", err)
+	}
+	return e
+}
+
+// 测试 AesGcm 各密钥长度、字符串与字节接口及附加认证数据
+func TestEncryptAesGcm(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		g, err := encrypt.NewAesGcm(bytes.Repeat([]byte{5}, size))
+		if err != nil {
+			t.Fatalf("AES-%d: NewAesGcm failed: %v", size*8, err)
+		}
+		s, err := g.EncryptString("hello", []byte("record:1"))
+		if err != nil {
+			t.Fatalf("AES-%d: EncryptString failed: %v", size*8, err)
+		}
+		if plain, err := g.DecryptString(s, []byte("record:1")); err != nil || plain != "hello" {
+			t.Errorf("AES-%d: Expected hello, got %q (%v)", size*8, plain, err)
+		}
+		if _, err := g.DecryptString(s, []byte("record:2")); err == nil {
+			t.Errorf("AES-%d: Expected mismatched aad to fail", size*8)
+		}
+		// 单段附加认证数据与拆开的多段不等价，即使内容恰好是多段的长度前缀编码
+		split, _ := g.EncryptBytes([]byte("x"), []byte("a"), []byte("b"))
+		if _, err := g.DecryptBytes(split, []byte("\x00\x00\x00\x01a\x00\x00\x00\x01b")); err == nil {
+			t.Errorf("AES-%d: Expected encoded single aad not to match split aad", size*8)
+		}
+		b, _ := g.EncryptBytes([]byte{0, 1, 2})
+		if plain, err := g.DecryptBytes(b); err != nil || !bytes.Equal(plain, []byte{0, 1, 2}) {
+			t.Errorf("AES-%d: Expected bytes round trip, got %v (%v)", size*8, plain, err)
+		}
+
+		//旧接口：Encrypt 输出 base64，Decrypt 接收解码后的数据
+		old, _ := g.Encrypt("legacy")
+		raw, _ := base64.StdEncoding.DecodeString(old)
+		if plain, err := g.Decrypt(raw); err != nil || plain != "legacy" {
+			t.Errorf("AES-%d: Expected legacy Decrypt to work, got %q (%v)", size*8, plain, err)
+		}
+	}
+	if _, err := encrypt.NewAesGcm([]byte("short")); err == nil {
+		t.Errorf("Expected invalid key length error")
+	}
+}
+
+// 测试分块流式加密与截断、篡改检测
+func TestEncryptStream(t *testing.T) {
+	key := bytes.Repeat([]byte{4}, 32)
+	encryptAll := func(data []byte, chunk int) []byte {
+		var buf bytes.Buffer
+		w, err := encrypt.NewEncryptWriterSize(&buf, key, chunk)
+		if err != nil {
+			t.Fatalf("NewEncryptWriterSize failed: %v", err)
+		}
+		//分多次写入，覆盖跨块边界
+		for len(data) > 0 {
+			n := min(len(data), 7)
+			if _, err := w.Write(data[:n]); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			data = data[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+	decryptAll := func(data []byte) ([]byte, error) {
+		r, err := encrypt.NewDecryptReader(bytes.NewReader(data), key)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	}
+
+	for _, size := range []int{0, 1, 63, 64, 65, 128, 1000} {
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+		sealed := encryptAll(plain, 64)
+		got, err := decryptAll(sealed)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("Size %d: Expected round trip, got %d bytes (%v)", size, len(got), err)
+		}
+	}
+
+	plain := bytes.Repeat([]byte("backup"), 100)
+	sealed := encryptAll(plain, 64)
+	header, chunk := 4+4+32+7, 64+16
+	if _, err := decryptAll(sealed[:header+2*chunk]); !errors.Is(err, encrypt.ErrStreamTruncated) {
+		t.Errorf("Expected ErrStreamTruncated at chunk boundary, got %v", err)
+	}
+	if _, err := decryptAll(sealed[:len(sealed)-3]); !errors.Is(err, encrypt.ErrStreamCorrupted) {
+		t.Errorf("Expected ErrStreamCorrupted for cut last chunk, got %v", err)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[header+chunk+5] ^= 1
+	if _, err := decryptAll(tampered); !errors.Is(err, encrypt.ErrStreamCorrupted) {
+		t.Errorf("Expected ErrStreamCorrupted for tampered chunk, got %v", err)
+	}
+	swapped := append([]byte(nil), sealed[:header]...)
+	swapped = append(swapped, sealed[header+chunk:header+2*chunk]...)
+	swapped = append(swapped, sealed[header:header+chunk]...)
+	swapped = append(swapped, sealed[header+2*chunk:]...)
+	if _, err := decryptAll(swapped); !errors.Is(err, encrypt.ErrStreamCorrupted) {
+		t.Errorf("Expected ErrStreamCorrupted for reordered chunks, got %v", err)
+	}
+	if _, err := decryptAll(append(sealed, 0)); err == nil {
+		t.Errorf("Expected trailing data to fail")
+	}
+	//每个流使用独立子密钥，另一个流的头部无法解密本流的分块
+	other := encryptAll(plain, 64)
+	spliced := append(append([]byte(nil), other[:header]...), sealed[header:]...)
+	if _, err := decryptAll(spliced); !errors.Is(err, encrypt.ErrStreamCorrupted) {
+		t.Errorf("Expected ErrStreamCorrupted for chunks under another stream header, got %v", err)
+	}
+	if _, err := encrypt.NewDecryptReader(bytes.NewReader([]byte("nope")), key); !errors.Is(err, encrypt.ErrStreamCorrupted) {
+		t.Errorf("Expected ErrStreamCorrupted for bad header, got %v", err)
+	}
+}
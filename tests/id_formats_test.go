@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/snow_node"
+)
+
+// 测试 ULID 生成、解析与单调性
+func TestULID(t *testing.T) {
+	g := snow_node.NewULIDGenerator()
+	prev := ""
+	for i := 0; i < 1000; i++ {
+		s, err := g.NewID()
+		if err != nil {
+			t.Fatalf("NewID failed: %v", err)
+		}
+		if len(s) != 26 || s <= prev {
+			t.Fatalf("Expected increasing 26-char ULIDs, got %q after %q", s, prev)
+		}
+		prev = s
+	}
+	u, err := snow_node.ParseULID(strings.ToLower(prev))
+	if err != nil {
+		t.Fatalf("ParseULID failed: %v", err)
+	}
+	if u.String() != prev {
+		t.Errorf("Round trip mismatch: %s != %s", u.String(), prev)
+	}
+	if d := time.Since(u.Time()); d < 0 || d > time.Second {
+		t.Errorf("Unexpected ULID time %v", u.Time())
+	}
+	if snow_node.ValidULID("8ZZZZZZZZZZZZZZZZZZZZZZZZZ") || snow_node.ValidULID("01ARZ3NDEKTSV4RRFFQ69G5FAU") {
+		t.Errorf("Expected overflowing or invalid ULIDs to be rejected")
+	}
+	if !snow_node.ValidULID("01ARZ3NDEKTSV4RRFFQ69G5FAV") {
+		t.Errorf("Expected spec example ULID to be valid")
+	}
+}
+
+// 测试 UUIDv4 与 UUIDv7
+func TestUUID(t *testing.T) {
+	v4, err := snow_node.NewUUIDv4Generator().NewID()
+	if err != nil {
+		t.Fatalf("NewID failed: %v", err)
+	}
+	u, err := snow_node.ParseUUID(v4)
+	if err != nil || u.Version() != 4 {
+		t.Errorf("Expected valid UUIDv4, got %s (%v)", v4, err)
+	}
+
+	g := snow_node.NewUUIDv7Generator()
+	prev := ""
+	for i := 0; i < 10000; i++ {
+		s, _ := g.NewID()
+		if s <= prev {
+			t.Fatalf("Expected increasing UUIDv7, got %s after %s", s, prev)
+		}
+		prev = s
+	}
+	u, err = snow_node.ParseUUID(strings.ToUpper(prev))
+	if err != nil || u.Version() != 7 || u.String() != prev {
+		t.Fatalf("Expected valid UUIDv7 round trip, got %s (%v)", u, err)
+	}
+	if d := time.Since(u.Time()); d < -10*time.Millisecond || d > time.Second {
+		t.Errorf("Unexpected UUIDv7 time %v", u.Time())
+	}
+	if snow_node.ValidUUID("not-a-uuid") || snow_node.ValidUUID("f81d4fae-7dec-11d0-0765-00a0c91e6bf6") {
+		t.Errorf("Expected invalid UUIDs to be rejected")
+	}
+}
+
+// 测试 KSUID
+func TestKSUID(t *testing.T) {
+	s, err := snow_node.NewKSUIDGenerator().NewID()
+	if err != nil {
+		t.Fatalf("NewID failed: %v", err)
+	}
+	k, err := snow_node.ParseKSUID(s)
+	if err != nil || k.String() != s || len(s) != 27 {
+		t.Fatalf("Expected KSUID round trip, got %s (%v)", s, err)
+	}
+	if d := time.Since(k.Time()); d < -time.Second || d > 2*time.Second {
+		t.Errorf("Unexpected KSUID time %v", k.Time())
+	}
+	if _, err := snow_node.ParseKSUID("0ujtsYcgvSTl8PAuAdqWYSMnLOv"); err != nil {
+		t.Errorf("Expected reference KSUID to parse: %v", err)
+	}
+	if snow_node.ValidKSUID("zzzzzzzzzzzzzzzzzzzzzzzzzzz") {
+		t.Errorf("Expected overflowing KSUID to be rejected")
+	}
+}
+
+// 测试自定义字母表的 NanoID
+func TestNanoID(t *testing.T) {
+	if _, err := snow_node.NewNanoIDGenerator("aa", 10); err == nil {
+		t.Errorf("Expected duplicate alphabet to be rejected")
+	}
+	var g snow_node.IDGenerator
+	nano, err := snow_node.NewNanoIDGenerator("0123456789abcdef", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = nano
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		s, err := g.NewID()
+		if err != nil || !nano.Valid(s) || seen[s] {
+			t.Fatalf("Unexpected NanoID %q (%v)", s, err)
+		}
+		seen[s] = true
+	}
+	if nano.Valid("0123456789ag") {
+		t.Errorf("Expected id with foreign character to be invalid")
+	}
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/jwt"
+)
+
+// userClaims 自定义声明
+type userClaims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role"`
+}
+
+// 测试各签名算法的签名与验签
+func TestJwtAlgorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	signing := []jwt.Key{
+		{ID: "hs256", Algorithm: jwt.HS256, Material: []byte("0123456789abcdef0123456789abcdef")},
+		{ID: "hs384", Algorithm: jwt.HS384, Material: []byte("secret-384")},
+		{ID: "hs512", Algorithm: jwt.HS512, Material: []byte("secret-512")},
+		{ID: "rs256", Algorithm: jwt.RS256, Material: rsaKey},
+		{ID: "es256", Algorithm: jwt.ES256, Material: ecKey},
+		{ID: "eddsa", Algorithm: jwt.EdDSA, Material: edKey},
+	}
+	//验签方只持有公钥
+	verify, _ := jwt.NewKeySet(
+		signing[0], signing[1], signing[2],
+		jwt.Key{ID: "rs256", Algorithm: jwt.RS256, Material: &rsaKey.PublicKey},
+		jwt.Key{ID: "es256", Algorithm: jwt.ES256, Material: &ecKey.PublicKey},
+		jwt.Key{ID: "eddsa", Algorithm: jwt.EdDSA, Material: edKey.Public()},
+	)
+
+	claims := userClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "42", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Role:             "admin",
+	}
+	for _, key := range signing {
+		token, err := jwt.Sign(claims, key)
+		if err != nil {
+			t.Fatalf("%s: Sign failed: %v", key.ID, err)
+		}
+		if strings.Contains(token, "=") {
+			t.Errorf("%s: Expected unpadded base64url, got %s", key.ID, token)
+		}
+		got, err := jwt.Parse[userClaims](token, verify)
+		if err != nil || got.Role != "admin" || got.Subject != "42" {
+			t.Errorf("%s: Expected claims back, got %+v (%v)", key.ID, got, err)
+		}
+		parts := strings.Split(token, ".")
+		tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"role":"root"}`)) + "." + parts[2]
+		if _, err := jwt.Parse[userClaims](tampered, verify); !errors.Is(err, jwt.ErrSignatureInvalid) {
+			t.Errorf("%s: Expected ErrSignatureInvalid, got %v", key.ID, err)
+		}
+	}
+
+	//公钥不能签名，头部 alg 与密钥算法不一致时拒绝
+	if _, err := jwt.Sign(claims, jwt.Key{ID: "rs256", Algorithm: jwt.RS256, Material: &rsaKey.PublicKey}); !errors.Is(err, jwt.ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	forged, _ := jwt.Sign(claims, jwt.Key{ID: "rs256", Algorithm: jwt.HS256, Material: []byte("guess")})
+	if _, err := jwt.Parse[userClaims](forged, verify); !errors.Is(err, jwt.ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey for algorithm confusion, got %v", err)
+	}
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + ".e30."
+	if _, err := jwt.Parse[userClaims](none, verify); !errors.Is(err, jwt.ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+}
+
+// 测试标准声明校验与密钥轮换
+func TestJwtClaimsAndRotation(t *testing.T) {
+	now := time.Now()
+	ks, _ := jwt.NewKeySet(jwt.Key{ID: "v1", Algorithm: jwt.HS256, Material: []byte("key-v1")})
+	sign := func(c jwt.RegisteredClaims) string {
+		token, err := ks.Sign(c)
+		if err != nil {
+			t.Fatalf("Sign failed: %v", err)
+		}
+		return token
+	}
+	parse := func(token string, opts ...jwt.ParseOption) error {
+		_, err := jwt.Parse[map[string]any](token, ks, opts...)
+		return err
+	}
+
+	cases := []struct {
+		name   string
+		claims jwt.RegisteredClaims
+		opts   []jwt.ParseOption
+		want   error
+	}{
+		{"expired", jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute))}, nil, jwt.ErrTokenExpired},
+		{"leeway", jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute))}, []jwt.ParseOption{jwt.WithLeeway(2 * time.Minute)}, nil},
+		{"nbf", jwt.RegisteredClaims{NotBefore: jwt.NewNumericDate(now.Add(time.Hour))}, nil, jwt.ErrTokenNotYetValid},
+		{"iat", jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(now.Add(time.Hour))}, nil, jwt.ErrIssuedInFuture},
+		{"iss", jwt.RegisteredClaims{Issuer: "a"}, []jwt.ParseOption{jwt.WithIssuer("b")}, jwt.ErrInvalidIssuer},
+		{"aud", jwt.RegisteredClaims{Audience: jwt.Audience{"x", "y"}}, []jwt.ParseOption{jwt.WithAudience("y")}, nil},
+		{"aud mismatch", jwt.RegisteredClaims{Audience: jwt.Audience{"x"}}, []jwt.ParseOption{jwt.WithAudience("y")}, jwt.ErrInvalidAudience},
+		{"exp required", jwt.RegisteredClaims{}, []jwt.ParseOption{jwt.WithExpirationRequired()}, jwt.ErrMissingExpiration},
+	}
+	for _, c := range cases {
+		if err := parse(sign(c.claims), c.opts...); !errors.Is(err, c.want) {
+			t.Errorf("%s: Expected %v, got %v", c.name, c.want, err)
+		}
+	}
+
+	old := sign(jwt.RegisteredClaims{Subject: "old"})
+	_ = ks.Add(jwt.Key{ID: "v2", Algorithm: jwt.HS256, Material: []byte("key-v2")})
+	_ = ks.SetPrimary("v2")
+	fresh := sign(jwt.RegisteredClaims{Subject: "new"})
+	if strings.Split(fresh, ".")[0] == strings.Split(old, ".")[0] {
+		t.Errorf("Expected new token header to carry the new kid")
+	}
+	if err := parse(old); err != nil {
+		t.Errorf("Expected old token to verify during rotation, got %v", err)
+	}
+	if err := ks.Remove("v2"); err == nil {
+		t.Errorf("Expected error removing primary key")
+	}
+	_ = ks.Remove("v1")
+	if err := parse(old); !errors.Is(err, jwt.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound after removing old key, got %v", err)
+	}
+	if err := parse(fresh); err != nil {
+		t.Errorf("Expected new token to verify, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/otp"
+)
+
+// RFC 4226 / RFC 6238 测试密钥
+const (
+	rfcSecretSHA1   = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	rfcSecretSHA256 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA"
+)
+
+// 测试 HOTP 计算与校验
+func TestOtpHOTP(t *testing.T) {
+	expected := []string{"755224", "287082", "359152", "969429", "338314"}
+	for i, want := range expected {
+		if code, err := otp.HOTP(rfcSecretSHA1, uint64(i)); err != nil || code != want {
+			t.Errorf("Counter %d: expected %s, got %s (%v)", i, want, code, err)
+		}
+	}
+	next, ok, err := otp.ValidateHOTP("969429", rfcSecretSHA1, 2, otp.WithSkew(1))
+	if err != nil || !ok || next != 4 {
+		t.Errorf("Expected look-ahead match with next counter 4, got %d %v %v", next, ok, err)
+	}
+	if _, ok, _ := otp.ValidateHOTP("338314", rfcSecretSHA1, 2, otp.WithSkew(1)); ok {
+		t.Errorf("Expected code beyond window to fail")
+	}
+	if _, err := otp.HOTP("not base32!", 0); !errors.Is(err, otp.ErrInvalidSecret) {
+		t.Errorf("Expected ErrInvalidSecret, got %v", err)
+	}
+	if _, err := otp.HOTP(rfcSecretSHA1, 0, otp.WithDigits(4)); !errors.Is(err, otp.ErrInvalidOption) {
+		t.Errorf("Expected ErrInvalidOption, got %v", err)
+	}
+}
+
+// 测试 TOTP 计算、漂移窗口与 otpauth URI
+func TestOtpTOTP(t *testing.T) {
+	at := time.Unix(59, 0)
+	if code, _ := otp.TOTP(rfcSecretSHA1, at, otp.WithDigits(8)); code != "94287082" {
+		t.Errorf("Expected 94287082, got %s", code)
+	}
+	if code, _ := otp.TOTP(rfcSecretSHA256, at, otp.WithDigits(8), otp.WithAlgorithm(otp.SHA256)); code != "46119246" {
+		t.Errorf("Expected 46119246, got %s", code)
+	}
+
+	secret, err := otp.GenerateSecret(0)
+	if err != nil || len(secret) != 32 {
+		t.Fatalf("Expected 32 char secret, got %q (%v)", secret, err)
+	}
+	now := time.Now()
+	prev, _ := otp.TOTP(secret, now.Add(-30*time.Second))
+	if ok, _ := otp.ValidateTOTP(prev, secret, now); !ok {
+		t.Errorf("Expected previous step to pass within skew")
+	}
+	if ok, _ := otp.ValidateTOTP(prev, secret, now, otp.WithSkew(0)); ok {
+		t.Errorf("Expected previous step to fail without skew")
+	}
+	cur, _ := otp.TOTP(secret, now)
+	if step, ok, _ := otp.MatchTOTP(cur, secret, now); !ok || step != uint64(now.Unix()/30) {
+		t.Errorf("Expected current step match, got %d %v", step, ok)
+	}
+
+	uri, err := otp.URI("Toolkit Admin", "alice@example.com", secret, otp.WithDigits(8))
+	if err != nil {
+		t.Fatalf("URI failed: %v", err)
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "otpauth" || u.Host != "totp" {
+		t.Fatalf("Unexpected uri %s (%v)", uri, err)
+	}
+	q := u.Query()
+	if u.Path != "/Toolkit Admin:alice@example.com" || q.Get("secret") != secret || q.Get("digits") != "8" || q.Get("period") != "30" || q.Get("issuer") != "Toolkit Admin" {
+		t.Errorf("Unexpected uri %s", uri)
+	}
+	if uri, _ := otp.HOTPURI("", "bob", secret, 5); uri != "otpauth://hotp/bob?algorithm=SHA1&counter=5&digits=6&secret="+secret {
+		t.Errorf("Unexpected hotp uri %s", uri)
+	}
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/pipeline"
+	"github.com/xierui921326/toolkit/queue"
+)
+
+// 测试有界队列 Put/Take/Close
+func TestBoundedQueue(t *testing.T) {
+	q := queue.NewBoundedQueue[int](1)
+	if !q.TryEnqueue(1) || q.TryEnqueue(2) {
+		t.Fatalf("Expected bounded queue to accept exactly one item")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Put(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Dequeue()
+	}()
+	if err := q.Put(context.Background(), 3); err != nil {
+		t.Errorf("Expected Put to succeed after dequeue, got %v", err)
+	}
+	q.Close()
+	if v, err := q.Take(context.Background()); err != nil || v != 3 {
+		t.Errorf("Expected 3, got %d (%v)", v, err)
+	}
+	if _, err := q.Take(context.Background()); !errors.Is(err, queue.ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed, got %v", err)
+	}
+}
+
+// 测试有序并发流水线
+func TestPipelineOrdered(t *testing.T) {
+	p := pipeline.New(context.Background())
+	input := make([]int, 100)
+	for i := range input {
+		input[i] = i
+	}
+	src := pipeline.Source(p, input)
+	squared := pipeline.Map(src, func(ctx context.Context, v int) (int, error) {
+		time.Sleep(time.Duration(rand.Intn(300)) * time.Microsecond)
+		return v * v, nil
+	}, pipeline.WithWorkers(8), pipeline.WithOrdered())
+	even := pipeline.Filter(squared, func(ctx context.Context, v int) (bool, error) {
+		return v%2 == 0, nil
+	}, pipeline.WithWorkers(4), pipeline.WithOrdered())
+	labels := pipeline.Map(even, func(ctx context.Context, v int) (string, error) {
+		return strconv.Itoa(v), nil
+	}, pipeline.WithWorkers(4), pipeline.WithOrdered(), pipeline.WithBuffer(2))
+
+	result, err := pipeline.Collect(labels)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(result) != 50 {
+		t.Fatalf("Expected 50 results, got %d", len(result))
+	}
+	for i, s := range result {
+		if want := strconv.Itoa(2 * i * 2 * i); s != want {
+			t.Fatalf("result[%d] = %s, want %s", i, s, want)
+		}
+	}
+}
+
+// 测试扇出扇入
+func TestPipelineFanOutFanIn(t *testing.T) {
+	p := pipeline.New(context.Background())
+	a := pipeline.Source(p, []int{1, 2, 3})
+	b := pipeline.Source(p, []int{10, 20})
+	expanded := pipeline.FlatMap(pipeline.Merge(a, b), func(ctx context.Context, v int) ([]int, error) {
+		return []int{v, -v}, nil
+	}, pipeline.WithWorkers(3))
+	result, err := pipeline.Collect(expanded)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	sort.Ints(result)
+	want := []int{-20, -10, -3, -2, -1, 1, 2, 3, 10, 20}
+	if len(result) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, result)
+	}
+	for i := range want {
+		if result[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, result)
+		}
+	}
+}
+
+// 测试错误传播与取消
+func TestPipelineError(t *testing.T) {
+	errBad := errors.New("bad item")
+	p := pipeline.New(context.Background())
+	src := pipeline.Generate(p, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+	})
+	err := pipeline.ForEach(src, func(ctx context.Context, v int) error {
+		if v == 42 {
+			return errBad
+		}
+		return nil
+	}, pipeline.WithWorkers(4))
+	if !errors.Is(err, errBad) {
+		t.Errorf("Expected errBad, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p = pipeline.New(ctx)
+	slow := pipeline.Map(pipeline.Source(p, []int{1, 2, 3}), func(ctx context.Context, v int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	cancel()
+	if _, err := pipeline.Collect(slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// 测试调用 Cancel 后 Collect 返回 context.Canceled
+func TestPipelineCancel(t *testing.T) {
+	p := pipeline.New(context.Background())
+	slow := pipeline.Map(pipeline.Source(p, []int{1, 2, 3}), func(ctx context.Context, v int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	p.Cancel()
+	if _, err := pipeline.Collect(slow); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	p = pipeline.New(context.Background())
+	if _, err := pipeline.Collect(pipeline.Source(p, []int{1, 2, 3})); err != nil {
+		t.Errorf("Expected nil error for a completed pipeline, got %v", err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Errorf("Expected repeated Wait to return nil, got %v", err)
+	}
+}
+
+// 测试有序阶段的重排窗口：首个元素处理缓慢时不会无限读取后续输入
+func TestPipelineOrderedWindow(t *testing.T) {
+	p := pipeline.New(context.Background())
+	input := make([]int, 5000)
+	for i := range input {
+		input[i] = i
+	}
+	var done atomic.Int64
+	var doneBeforeHead int64
+	out := pipeline.Map(pipeline.Source(p, input), func(ctx context.Context, v int) (int, error) {
+		if v == 0 {
+			time.Sleep(100 * time.Millisecond)
+			doneBeforeHead = done.Load()
+		}
+		done.Add(1)
+		return v, nil
+	}, pipeline.WithWorkers(4), pipeline.WithBuffer(4), pipeline.WithOrdered())
+	result, err := pipeline.Collect(out)
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(result) != len(input) || result[len(result)-1] != len(input)-1 {
+		t.Fatalf("Expected %d ordered results, got %d", len(input), len(result))
+	}
+	if doneBeforeHead > 16 {
+		t.Errorf("Expected reorder window to bound finished items, got %d while head was blocked", doneBeforeHead)
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/scheduler"
+	"github.com/xierui921326/toolkit/worker_pool"
+)
+
+// 测试 cron 表达式解析与下一次执行时间
+func TestCronNext(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	base := time.Date(2024, 3, 15, 10, 30, 0, 0, loc) // 周五
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 45, 0, 0, loc)},
+		{"0 9 * * mon-fri", time.Date(2024, 3, 18, 9, 0, 0, 0, loc)},
+		{"0 0 1 */2 *", time.Date(2024, 5, 1, 0, 0, 0, 0, loc)},
+		{"30 10 15 3 *", time.Date(2025, 3, 15, 10, 30, 0, 0, loc)},
+		{"0 0 13 * 5", time.Date(2024, 3, 22, 0, 0, 0, 0, loc)},
+		{"*/20 30 10 * * *", time.Date(2024, 3, 15, 10, 30, 20, 0, loc)},
+		{"@daily", time.Date(2024, 3, 16, 0, 0, 0, 0, loc)},
+	}
+	for _, c := range cases {
+		s, err := scheduler.Cron(c.spec)
+		if err != nil {
+			t.Errorf("Cron(%q) failed: %v", c.spec, err)
+			continue
+		}
+		if got := s.Next(base); !got.Equal(c.want) {
+			t.Errorf("Cron(%q).Next = %v, want %v", c.spec, got, c.want)
+		}
+	}
+
+	for _, spec := range []string{"* * *", "61 * * * *", "5-1 * * * *", "*/0 * * * *", "@every -1s"} {
+		if _, err := scheduler.Cron(spec); err == nil {
+			t.Errorf("Expected Cron(%q) to fail", spec)
+		}
+	}
+}
+
+// 测试调度器的重叠策略与暂停、删除
+func TestSchedulerOverlapAndControl(t *testing.T) {
+	wp := worker_pool.NewWorkerPool(4, worker_pool.WithQueueSize(8))
+	wp.Run()
+	defer wp.Stop()
+	s := scheduler.New(wp)
+	s.Start()
+	defer s.Stop()
+
+	var slowRuns, fastRuns atomic.Int32
+	err := s.Add("slow", scheduler.Every(10*time.Millisecond), testJob{fn: func() {
+		slowRuns.Add(1)
+		time.Sleep(60 * time.Millisecond)
+	}})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := s.Add("slow", scheduler.Every(time.Second), testJob{fn: func() {}}); !errors.Is(err, scheduler.ErrEntryExists) {
+		t.Errorf("Expected ErrEntryExists, got %v", err)
+	}
+	_ = s.Add("fast", scheduler.Every(10*time.Millisecond), testJob{fn: func() { fastRuns.Add(1) }}, scheduler.WithOverlap(scheduler.OverlapAllow))
+	once := make(chan struct{})
+	_ = s.Add("once", scheduler.At(time.Now().Add(20*time.Millisecond)), testJob{fn: func() { close(once) }})
+
+	select {
+	case <-once:
+	case <-time.After(time.Second):
+		t.Fatal("one-off schedule did not run")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	infos := s.List()
+	if len(infos) != 3 || infos[0].Name != "fast" || infos[2].Name != "slow" {
+		t.Fatalf("Unexpected entries %+v", infos)
+	}
+	if !infos[1].Next.IsZero() {
+		t.Errorf("Expected one-off schedule to have no next run, got %v", infos[1].Next)
+	}
+	if infos[2].Skipped == 0 {
+		t.Errorf("Expected overlapping slow runs to be skipped")
+	}
+	if slowRuns.Load() > 3 {
+		t.Errorf("Expected slow job to skip overlaps, ran %d times", slowRuns.Load())
+	}
+
+	_ = s.Pause("fast")
+	time.Sleep(20 * time.Millisecond)
+	paused := fastRuns.Load()
+	time.Sleep(50 * time.Millisecond)
+	if fastRuns.Load() != paused {
+		t.Errorf("Expected paused schedule not to run")
+	}
+	_ = s.Resume("fast")
+	time.Sleep(50 * time.Millisecond)
+	if fastRuns.Load() == paused {
+		t.Errorf("Expected resumed schedule to run")
+	}
+
+	if err := s.Remove("fast"); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+	if err := s.Remove("fast"); !errors.Is(err, scheduler.ErrEntryNotFound) {
+		t.Errorf("Expected ErrEntryNotFound, got %v", err)
+	}
+}
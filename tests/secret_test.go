@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/secret"
+)
+
+// 测试 N 进制编解码
+func TestSecretCodec(t *testing.T) {
+	c := secret.MustCodec(secret.Base62Alphabet)
+	for _, n := range []uint64{0, 1, 61, 62, 1288834974657, 1 << 62, math.MaxUint64} {
+		code := c.Encode(n)
+		got, err := c.Decode(code)
+		if err != nil || got != n {
+			t.Errorf("Expected %d, got %d from %q (%v)", n, got, code, err)
+		}
+	}
+	if code := c.Encode(61); code != "Z" {
+		t.Errorf("Expected Z, got %s", code)
+	}
+	if _, err := c.Decode("LygHa16AHYG"); !errors.Is(err, secret.ErrCodeOverflow) {
+		t.Errorf("Expected ErrCodeOverflow, got %v", err)
+	}
+	if _, err := c.Decode("ab-c"); !errors.Is(err, secret.ErrInvalidCode) {
+		t.Errorf("Expected ErrInvalidCode, got %v", err)
+	}
+	if _, err := secret.NewCodec("aab"); !errors.Is(err, secret.ErrInvalidAlphabet) {
+		t.Errorf("Expected ErrInvalidAlphabet, got %v", err)
+	}
+
+	big1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	code, _ := c.EncodeBig(big1)
+	if got, err := c.DecodeBig(code); err != nil || got.Cmp(big1) != 0 {
+		t.Errorf("Expected %s, got %s (%v)", big1, got, err)
+	}
+}
+
+// 测试混淆与最小长度
+func TestSecretCodecSalt(t *testing.T) {
+	c := secret.MustCodec(secret.Base62Alphabet, secret.WithSalt("toolkit"), secret.WithMinLength(8))
+	other := secret.MustCodec(secret.Base62Alphabet, secret.WithSalt("other"), secret.WithMinLength(8))
+	seen := make(map[string]bool)
+	for n := uint64(0); n < 2000; n++ {
+		code := c.Encode(n)
+		if len(code) < 8 || seen[code] {
+			t.Fatalf("Unexpected code %q for %d", code, n)
+		}
+		seen[code] = true
+		if got, err := c.Decode(code); err != nil || got != n {
+			t.Fatalf("Expected %d, got %d (%v)", n, got, err)
+		}
+		if got, err := other.Decode(code); err == nil && got == n {
+			t.Fatalf("Expected different salt to not decode %q", code)
+		}
+	}
+	if c.Encode(1)[1:] == c.Encode(2)[1:] {
+		t.Errorf("Expected obfuscated codes to differ")
+	}
+}
+
+// 测试应用凭证签发与校验
+func TestSecretCredential(t *testing.T) {
+	cred, err := secret.NewCredential()
+	if err != nil {
+		t.Fatalf("NewCredential failed: %v", err)
+	}
+	if !strings.HasPrefix(cred.AccessKey, "ak_") || len(cred.AccessKey) != 24 {
+		t.Errorf("Unexpected access key %q", cred.AccessKey)
+	}
+	if !strings.HasPrefix(cred.SecretKey, "sk_") || len(cred.SecretKey) != 44 {
+		t.Errorf("Unexpected secret key %q", cred.SecretKey)
+	}
+	if err := secret.ValidateAccessKey(cred.AccessKey); err != nil {
+		t.Errorf("Expected valid access key, got %v", err)
+	}
+	if err := secret.ValidateSecretKey(cred.AccessKey); !errors.Is(err, secret.ErrInvalidCredential) {
+		t.Errorf("Expected ErrInvalidCredential for wrong prefix, got %v", err)
+	}
+
+	//单个字符输入错误应被校验位发现
+	b := []byte(cred.SecretKey)
+	if b[5] == 'a' {
+		b[5] = 'b'
+	} else {
+		b[5] = 'a'
+	}
+	if err := secret.ValidateSecretKey(string(b)); !errors.Is(err, secret.ErrCredentialChecksum) {
+		t.Errorf("Expected ErrCredentialChecksum, got %v", err)
+	}
+
+	g, err := secret.NewCredentialGenerator(secret.WithCredentialAlphabet("0123456789ABCDEF"), secret.WithPrefixes("pk-", "ps-"), secret.WithKeyLength(8))
+	if err != nil {
+		t.Fatalf("NewCredentialGenerator failed: %v", err)
+	}
+	cred, _ = g.Generate()
+	if !strings.HasPrefix(cred.AccessKey, "pk-") || len(cred.AccessKey) != 12 || g.ValidateAccessKey(cred.AccessKey) != nil {
+		t.Errorf("Unexpected access key %q", cred.AccessKey)
+	}
+	if _, err := secret.NewCredentialGenerator(secret.WithPrefixes("x_", "x_")); err == nil {
+		t.Errorf("Expected error for identical prefixes")
+	}
+	if a, b := secret.GetAppSecret("app"), secret.GetAppSecret("app"); len(a) != 40 || a == b {
+		t.Errorf("Expected random 40 char secrets, got %s and %s", a, b)
+	}
+}
+
+// 测试凭证存储的签发、轮换、过期与吊销
+func TestSecretCredentialStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	mem := secret.NewMemoryCredentialStore()
+	mem.Now = func() time.Time { return now }
+	file, err := secret.NewFileCredentialStore(filepath.Join(t.TempDir(), "creds", "store.json"))
+	if err != nil {
+		t.Fatalf("NewFileCredentialStore failed: %v", err)
+	}
+
+	for name, store := range map[string]secret.AppCredentialStore{"memory": mem, "file": file} {
+		old, oldInfo, err := store.Issue(ctx, "app1", 0)
+		if err != nil || secret.ValidateSecretKey(old) != nil {
+			t.Fatalf("%s: Issue failed: %q (%v)", name, old, err)
+		}
+		if info, err := store.Verify(ctx, "app1", old); err != nil || info.ID != oldInfo.ID {
+			t.Errorf("%s: Expected secret to verify, got %v", name, err)
+		}
+		if _, err := store.Verify(ctx, "app1", old+"x"); !errors.Is(err, secret.ErrSecretMismatch) {
+			t.Errorf("%s: Expected ErrSecretMismatch, got %v", name, err)
+		}
+		if _, err := store.Verify(ctx, "missing", old); !errors.Is(err, secret.ErrSecretMismatch) {
+			t.Errorf("%s: Expected ErrSecretMismatch for unknown app, got %v", name, err)
+		}
+		if want := old[len(old)-5 : len(old)-1]; oldInfo.Hint != want {
+			t.Errorf("%s: Expected hint %q without check char, got %q", name, want, oldInfo.Hint)
+		}
+
+		//轮换期间新旧 secret 同时有效
+		fresh, _, err := store.Rotate(ctx, "app1", 0, time.Hour)
+		if err != nil {
+			t.Fatalf("%s: Rotate failed: %v", name, err)
+		}
+		for _, s := range []string{old, fresh} {
+			if _, err := store.Verify(ctx, "app1", s); err != nil {
+				t.Errorf("%s: Expected secret valid during grace period, got %v", name, err)
+			}
+		}
+		list, _ := store.List(ctx, "app1")
+		if len(list) != 2 || list[0].ExpiresAt.IsZero() || !list[1].ExpiresAt.IsZero() {
+			t.Errorf("%s: Unexpected secrets after rotation: %+v", name, list)
+		}
+
+		legacy := secret.GetAppSecret("app1")
+		info, _ := store.Import(ctx, "app1", legacy, 0)
+		if err := store.Revoke(ctx, "app1", info.ID); err != nil {
+			t.Fatalf("%s: Revoke failed: %v", name, err)
+		}
+		if _, err := store.Verify(ctx, "app1", legacy); !errors.Is(err, secret.ErrSecretMismatch) {
+			t.Errorf("%s: Expected revoked secret to fail, got %v", name, err)
+		}
+		if err := store.Revoke(ctx, "app1", "missing"); !errors.Is(err, secret.ErrCredentialNotFound) {
+			t.Errorf("%s: Expected ErrCredentialNotFound, got %v", name, err)
+		}
+	}
+
+	//宽限期结束后旧 secret 过期
+	old, _, _ := mem.Issue(ctx, "app2", 0)
+	_, _, _ = mem.Rotate(ctx, "app2", 0, time.Minute)
+	now = now.Add(2 * time.Minute)
+	if _, err := mem.Verify(ctx, "app2", old); !errors.Is(err, secret.ErrSecretMismatch) {
+		t.Errorf("Expected expired secret to fail, got %v", err)
+	}
+
+	data, _ := os.ReadFile(file.Path())
+	if strings.Contains(string(data), "sk_") {
+		t.Errorf("Expected store file to not contain plaintext secrets")
+	}
+}
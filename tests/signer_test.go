@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/secret"
+	"github.com/xierui921326/toolkit/utils"
+)
+
+// 测试规范查询串与 utils.SortedQueryString 一致
+func TestSecretCanonicalQuery(t *testing.T) {
+	params := map[string]string{"b": "x y", "a": "1", "signature": "ignored"}
+	query := url.Values{}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	c := secret.CanonicalRequest{Method: "GET", Query: query}
+	if got, want := strings.Split(c.String(), "\n")[2], utils.SortedQueryString(params); got != want || want != "a=1&b=x%20y" {
+		t.Errorf("Expected canonical query %q, got %q", want, got)
+	}
+	if got := utils.SortedQueryValues(url.Values{"b": {"2", "1"}, "a": {"z"}}); got != "a=z&b=1&b=2" {
+		t.Errorf("Expected multi-value query sorted by key and value, got %q", got)
+	}
+}
+
+// 测试请求签名与验签
+func TestSecretSignVerify(t *testing.T) {
+	cred, _ := secret.NewCredential()
+	lookup := func(ctx context.Context, accessKey string) ([]string, error) {
+		if accessKey != cred.AccessKey {
+			return nil, secret.ErrCredentialNotFound
+		}
+		return []string{"old-secret", cred.SecretKey}, nil
+	}
+	v := secret.NewVerifier(lookup, secret.WithClockSkew(time.Minute))
+	signer := secret.NewSigner(cred.AccessKey, cred.SecretKey)
+
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/v1/orders?b=2&a=1&a=0", strings.NewReader(`{"id":1}`))
+	}
+
+	r := newRequest()
+	if err := signer.Sign(r); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if key, err := v.Verify(r); err != nil || key != cred.AccessKey {
+		t.Fatalf("Expected request to verify, got %v", err)
+	}
+	if _, err := v.Verify(r); !errors.Is(err, secret.ErrNonceReused) {
+		t.Errorf("Expected ErrNonceReused, got %v", err)
+	}
+	if body, _ := io.ReadAll(r.Body); string(body) != `{"id":1}` {
+		t.Errorf("Expected body to be readable after verify, got %q", body)
+	}
+
+	r = newRequest()
+	_ = signer.Sign(r)
+	r.URL.RawQuery = "a=1&b=3"
+	if _, err := v.Verify(r); !errors.Is(err, secret.ErrBadSignature) {
+		t.Errorf("Expected ErrBadSignature for tampered query, got %v", err)
+	}
+
+	r = newRequest()
+	_ = signer.Sign(r)
+	r.Body = io.NopCloser(strings.NewReader(`{"id":2}`))
+	if _, err := v.Verify(r); !errors.Is(err, secret.ErrContentHash) {
+		t.Errorf("Expected ErrContentHash, got %v", err)
+	}
+
+	signer.Now = func() time.Time { return time.Now().Add(-2 * time.Minute) }
+	r = newRequest()
+	_ = signer.Sign(r)
+	if _, err := v.Verify(r); !errors.Is(err, secret.ErrSignatureExpired) {
+		t.Errorf("Expected ErrSignatureExpired, got %v", err)
+	}
+	signer.Now = time.Now
+
+	if _, err := v.Verify(newRequest()); !errors.Is(err, secret.ErrMissingSignature) {
+		t.Errorf("Expected ErrMissingSignature, got %v", err)
+	}
+}
+
+// 测试客户端 RoundTripper 与服务端中间件
+func TestSecretSignMiddleware(t *testing.T) {
+	cred, _ := secret.NewCredential()
+	v := secret.NewVerifier(func(ctx context.Context, accessKey string) ([]string, error) {
+		return []string{cred.SecretKey}, nil
+	})
+	srv := httptest.NewServer(v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _ := secret.AccessKeyFromContext(r.Context())
+		body, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, key+":"+string(body))
+	})))
+	defer srv.Close()
+
+	client := &http.Client{Transport: secret.NewSigner(cred.AccessKey, cred.SecretKey).Transport(nil)}
+	resp, err := client.Post(srv.URL+"/path%20x?q=hello%20world&q=a%2Bb", "application/json", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != cred.AccessKey+":payload" {
+		t.Errorf("Expected signed request to pass, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for unsigned request, got %d", resp.StatusCode)
+	}
+}
+
+// 测试内存 nonce 存储的过期与清理
+func TestSecretNonceStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := secret.NewMemoryNonceStore(0)
+	store.Now = func() time.Time { return now }
+
+	if ok, _ := store.Add(ctx, "n1", time.Minute); !ok {
+		t.Fatalf("Expected first nonce to be accepted")
+	}
+	if ok, _ := store.Add(ctx, "n1", time.Minute); ok {
+		t.Errorf("Expected duplicate nonce to be rejected")
+	}
+	for i := 0; i < 100; i++ {
+		_, _ = store.Add(ctx, strconv.Itoa(i), time.Second)
+	}
+	now = now.Add(2 * time.Second)
+	store.Sweep()
+	if n := store.Len(); n != 1 {
+		t.Errorf("Expected 1 nonce after sweep, got %d", n)
+	}
+	now = now.Add(time.Minute)
+	if ok, _ := store.Add(ctx, "n1", time.Minute); !ok {
+		t.Errorf("Expected expired nonce to be accepted again")
+	}
+
+	bg := secret.NewMemoryNonceStore(10 * time.Millisecond)
+	defer bg.Close()
+	_, _ = bg.Add(ctx, "short", time.Millisecond)
+	waitFor(t, func() bool { return bg.Len() == 0 })
+
+	//验签器使用自定义存储
+	cred, _ := secret.NewCredential()
+	v := secret.NewVerifier(func(ctx context.Context, accessKey string) ([]string, error) {
+		return []string{cred.SecretKey}, nil
+	}, secret.WithNonceStore(store))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	_ = secret.NewSigner(cred.AccessKey, cred.SecretKey).Sign(r)
+	if _, err := v.Verify(r); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if ok, _ := store.Add(ctx, cred.AccessKey+":"+r.Header.Get(secret.HeaderNonce), time.Minute); ok {
+		t.Errorf("Expected verifier to record nonce in the custom store")
+	}
+}
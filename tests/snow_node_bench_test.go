@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/xierui921326/toolkit/snow_node"
+)
+
+// 基准测试单个生成 ID
+func BenchmarkSnowNodeNextID(b *testing.B) {
+	g, _ := snow_node.New(1)
+	for i := 0; i < b.N; i++ {
+		_, _ = g.NextID()
+	}
+}
+
+// 基准测试并发生成 ID
+func BenchmarkSnowNodeNextIDParallel(b *testing.B) {
+	g, _ := snow_node.New(1)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = g.NextID()
+		}
+	})
+}
+
+// 基准测试批量生成 ID，每次 1000 个
+func BenchmarkSnowNodeNextIDs(b *testing.B) {
+	g, _ := snow_node.New(1)
+	for i := 0; i < b.N; i++ {
+		_, _ = g.NextIDs(1000)
+	}
+}
+
+// 基准测试分片并发生成 ID
+func BenchmarkSnowNodeShardedParallel(b *testing.B) {
+	sg, _ := snow_node.NewSharded([]int64{1, 2, 3, 4, 5, 6, 7, 8})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, _ = sg.NextID()
+		}
+	})
+}
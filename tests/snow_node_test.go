@@ -0,0 +1,418 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/snow_node"
+)
+
+// 测试显式节点 ID 与配置错误
+func TestSnowNodeNew(t *testing.T) {
+	g, err := snow_node.New(7)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if g.NodeID() != 7 {
+		t.Errorf("Expected node id 7, got %d", g.NodeID())
+	}
+	seen := make(map[int64]bool)
+	for i := 0; i < 10000; i++ {
+		id, err := g.NextID()
+		if err != nil {
+			t.Fatalf("NextID failed: %v", err)
+		}
+		if seen[id] {
+			t.Fatalf("Duplicate id %d", id)
+		}
+		seen[id] = true
+	}
+
+	if _, err := snow_node.New(snow_node.MaxNodeID + 1); !errors.Is(err, snow_node.ErrInvalidNodeID) {
+		t.Errorf("Expected ErrInvalidNodeID, got %v", err)
+	}
+	if _, err := snow_node.New(snow_node.AutoNodeID); err == nil {
+		t.Errorf("Expected error for AutoNodeID without resolvers")
+	}
+}
+
+// 测试节点 ID 解析器
+func TestSnowNodeResolvers(t *testing.T) {
+	dir := t.TempDir()
+	lease := filepath.Join(dir, "node_id")
+	if err := os.WriteFile(lease, []byte("42\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_SNOW_NODE_ID", "")
+	g, err := snow_node.New(snow_node.AutoNodeID,
+		snow_node.WithEnv("TEST_SNOW_NODE_ID"),
+		snow_node.WithLeaseFile(filepath.Join(dir, "missing")),
+		snow_node.WithLeaseFile(lease))
+	if err != nil || g.NodeID() != 42 {
+		t.Fatalf("Expected node id 42 from lease file, got %v (%v)", g, err)
+	}
+
+	t.Setenv("TEST_SNOW_NODE_ID", "9")
+	g, err = snow_node.New(snow_node.AutoNodeID, snow_node.WithEnv("TEST_SNOW_NODE_ID"), snow_node.WithLeaseFile(lease))
+	if err != nil || g.NodeID() != 9 {
+		t.Fatalf("Expected node id 9 from env, got %v (%v)", g, err)
+	}
+
+	t.Setenv("TEST_SNOW_NODE_ID", "abc")
+	if _, err := snow_node.New(snow_node.AutoNodeID, snow_node.WithEnv("TEST_SNOW_NODE_ID"), snow_node.WithLeaseFile(lease)); err == nil {
+		t.Errorf("Expected error for invalid env value")
+	}
+
+	if id := snow_node.NodeIDFromIP(net.ParseIP("10.0.3.7")); id != 3<<8|7 {
+		t.Errorf("Expected node id %d, got %d", 3<<8|7, id)
+	}
+}
+
+// 测试包级默认生成器
+func TestSnowNodeGetID(t *testing.T) {
+	if err := snow_node.Init(1); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if a, b := snow_node.GetID(), snow_node.GetID(); a == "" || a == b {
+		t.Errorf("Expected distinct ids, got %s and %s", a, b)
+	}
+
+	// 默认生成器租约失效：GetIDE 返回错误，GetID 返回空串而不改用随机节点
+	g, err := snow_node.New(snow_node.AutoNodeID, snow_node.WithLayout(snow_node.Layout{Epoch: snow_node.DefaultLayout.Epoch, NodeBits: 5, StepBits: 17}),
+		snow_node.WithAllocator(snow_node.NewMemoryAllocator(1), "a", time.Minute), snow_node.WithClockPolicy(snow_node.ClockError))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	_ = g.Close()
+	snow_node.SetDefault(g)
+	defer snow_node.SetDefault(nil)
+	if _, err := snow_node.GetIDE(); !errors.Is(err, snow_node.ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost, got %v", err)
+	}
+	if _, err := snow_node.GetIDsE(2); !errors.Is(err, snow_node.ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost, got %v", err)
+	}
+	if id := snow_node.GetID(); id != "" {
+		t.Errorf("Expected empty id after lease lost, got %s", id)
+	}
+	if s := snow_node.GetIDs(2); s != nil {
+		t.Errorf("Expected nil ids after lease lost, got %v", s)
+	}
+
+	// 无法确定节点 ID：GetIDE 返回错误，GetID 改用随机节点的后备生成器
+	snow_node.SetDefault(nil)
+	t.Setenv(snow_node.EnvNodeID, "abc")
+	if _, err := snow_node.GetIDE(); err == nil {
+		t.Errorf("Expected error for invalid %s", snow_node.EnvNodeID)
+	}
+	if a, b := snow_node.GetID(), snow_node.GetID(); a == "" || a == b {
+		t.Errorf("Expected distinct fallback ids, got %s and %s", a, b)
+	}
+	if s := snow_node.GetIDs(2); len(s) != 2 || s[0] == s[1] {
+		t.Errorf("Expected 2 distinct fallback ids, got %v", s)
+	}
+}
+
+// 测试内存节点 ID 分配器
+func TestSnowNodeMemoryAllocator(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	alloc := snow_node.NewMemoryAllocator(1)
+	alloc.Now = func() time.Time { return now }
+
+	a, err := alloc.Acquire(ctx, "a", snow_node.MaxNodeID, time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	b, _ := alloc.Acquire(ctx, "b", snow_node.MaxNodeID, time.Minute)
+	if a.NodeID == b.NodeID {
+		t.Fatalf("Expected distinct node ids, got %d", a.NodeID)
+	}
+	if _, err := alloc.Acquire(ctx, "c", snow_node.MaxNodeID, time.Minute); !errors.Is(err, snow_node.ErrNoFreeNodeID) {
+		t.Errorf("Expected ErrNoFreeNodeID, got %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	c, err := alloc.Acquire(ctx, "c", snow_node.MaxNodeID, time.Minute)
+	if err != nil || c.NodeID != a.NodeID {
+		t.Fatalf("Expected expired node id %d to be reused, got %v (%v)", a.NodeID, c, err)
+	}
+	if err := alloc.Renew(ctx, a, time.Minute); !errors.Is(err, snow_node.ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost, got %v", err)
+	}
+}
+
+// 测试生成器租约续约与失效
+func TestSnowNodeGeneratorLease(t *testing.T) {
+	alloc := snow_node.NewMemoryAllocator(-1)
+	g, err := snow_node.New(snow_node.AutoNodeID, snow_node.WithAllocator(alloc, "test", 30*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if _, err := g.NextID(); err != nil {
+		t.Fatalf("Expected lease to be renewed, got %v", err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := g.NextID(); !errors.Is(err, snow_node.ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost after Close, got %v", err)
+	}
+	lease, _ := alloc.Acquire(context.Background(), "next", snow_node.MaxNodeID, time.Minute)
+	if lease.NodeID != g.NodeID() {
+		t.Errorf("Expected released node id %d to be reused, got %d", g.NodeID(), lease.NodeID)
+	}
+
+	// 分配器的上限由生成器布局决定
+	small := snow_node.Layout{Epoch: snow_node.DefaultLayout.Epoch, NodeBits: 1, StepBits: 12}
+	bounded := snow_node.NewMemoryAllocator(-1)
+	for i := 0; i < 2; i++ {
+		if _, err := snow_node.New(snow_node.AutoNodeID, snow_node.WithLayout(small), snow_node.WithAllocator(bounded, "small", time.Minute)); err != nil {
+			t.Fatalf("New %d failed: %v", i, err)
+		}
+	}
+	if _, err := snow_node.New(snow_node.AutoNodeID, snow_node.WithLayout(small), snow_node.WithAllocator(bounded, "small", time.Minute)); !errors.Is(err, snow_node.ErrNoFreeNodeID) {
+		t.Errorf("Expected ErrNoFreeNodeID beyond layout bound, got %v", err)
+	}
+
+	if _, err := snow_node.New(snow_node.AutoNodeID, snow_node.WithAllocator(alloc, "zero", 0)); !errors.Is(err, snow_node.ErrInvalidTTL) {
+		t.Errorf("Expected ErrInvalidTTL for zero ttl, got %v", err)
+	}
+	lost := make(chan error, 1)
+	snow_node.KeepAlive(context.Background(), alloc, lease, 0, func(err error) { lost <- err })
+	if err := <-lost; !errors.Is(err, snow_node.ErrInvalidTTL) {
+		t.Errorf("Expected KeepAlive to report ErrInvalidTTL, got %v", err)
+	}
+}
+
+// 测试文件节点 ID 分配器并发租用
+func TestSnowNodeFileAllocator(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	ids := make(chan int64, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// 每个协程使用独立的分配器实例，模拟多个进程
+			alloc, err := snow_node.NewFileAllocator(dir, -1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			lease, err := alloc.Acquire(context.Background(), "proc", snow_node.MaxNodeID, time.Minute)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			ids <- lease.NodeID
+		}(i)
+	}
+	wg.Wait()
+	close(ids)
+	seen := make(map[int64]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("Duplicate node id %d", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 20 {
+		t.Errorf("Expected 20 leases, got %d", len(seen))
+	}
+}
+
+// 测试雪花 ID 解析与时间范围
+func TestSnowNodeParse(t *testing.T) {
+	g, _ := snow_node.New(123)
+	before := time.Now().Truncate(time.Millisecond)
+	id, _ := g.NextID()
+	after := time.Now()
+
+	info, err := snow_node.ParseString(strconv.FormatInt(id, 10))
+	if err != nil {
+		t.Fatalf("ParseString failed: %v", err)
+	}
+	if info.NodeID != 123 || info.ID != id {
+		t.Errorf("Unexpected info %+v", info)
+	}
+	if info.Time.Before(before) || info.Time.After(after) {
+		t.Errorf("Expected time between %v and %v, got %v", before, after, info.Time)
+	}
+
+	min, max := snow_node.IDRange(before, after)
+	if id < min || id > max {
+		t.Errorf("Expected %d within [%d, %d]", id, min, max)
+	}
+	if snow_node.MaxIDForTime(before)+1 != snow_node.MinIDForTime(before.Add(time.Millisecond)) {
+		t.Errorf("Expected adjacent milliseconds to have adjacent id ranges")
+	}
+	if _, err := snow_node.ParseString("abc"); err == nil {
+		t.Errorf("Expected error for invalid id")
+	}
+}
+
+// 测试自定义位布局
+func TestSnowNodeLayout(t *testing.T) {
+	layout := snow_node.Layout{Epoch: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), NodeBits: 5, StepBits: 16}
+	g, err := snow_node.New(31, snow_node.WithLayout(layout))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for i := 0; i < 70000; i++ {
+		if _, err := g.NextID(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, _ := g.NextID()
+	info := g.Layout().Parse(id)
+	if info.NodeID != 31 || time.Since(info.Time) > time.Second {
+		t.Errorf("Unexpected info %+v", info)
+	}
+	if _, err := snow_node.New(32, snow_node.WithLayout(layout)); !errors.Is(err, snow_node.ErrInvalidNodeID) {
+		t.Errorf("Expected ErrInvalidNodeID, got %v", err)
+	}
+	if _, err := snow_node.New(1, snow_node.WithLayout(snow_node.Layout{NodeBits: 10, StepBits: 12})); err == nil {
+		t.Errorf("Expected error for zero epoch")
+	}
+	if _, err := snow_node.New(1, snow_node.WithEpoch(time.Now().Add(time.Hour))); err == nil {
+		t.Errorf("Expected error for future epoch")
+	}
+	if _, err := snow_node.New(1, snow_node.WithLayout(snow_node.Layout{Epoch: layout.Epoch, NodeBits: 20, StepBits: 20})); err == nil {
+		t.Errorf("Expected error for oversized layout")
+	}
+	if _, err := snow_node.New(1, snow_node.WithLayout(snow_node.Layout{Epoch: layout.Epoch, NodeBits: 250, StepBits: 10})); err == nil {
+		t.Errorf("Expected error for node bits whose uint8 sum wraps around")
+	}
+}
+
+// 测试时钟回拨处理策略
+func TestSnowNodeClockRollback(t *testing.T) {
+	var offset atomic.Int64
+	clock := func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }
+
+	g, _ := snow_node.New(1, snow_node.WithClock(clock), snow_node.WithClockPolicy(snow_node.ClockError))
+	first, _ := g.NextID()
+	offset.Store(int64(-time.Second))
+	if _, err := g.NextID(); !errors.Is(err, snow_node.ErrClockBackwards) {
+		t.Errorf("Expected ErrClockBackwards, got %v", err)
+	}
+
+	offset.Store(0)
+	g, _ = snow_node.New(1, snow_node.WithClock(clock), snow_node.WithClockPolicy(snow_node.ClockLogical))
+	first, _ = g.NextID()
+	offset.Store(int64(-time.Second))
+	prev := first
+	for i := 0; i < 10000; i++ {
+		id, err := g.NextID()
+		if err != nil || id <= prev {
+			t.Fatalf("Expected increasing ids with logical clock, got %d after %d (%v)", id, prev, err)
+		}
+		prev = id
+	}
+
+	offset.Store(0)
+	g, _ = snow_node.New(1, snow_node.WithClock(clock), snow_node.WithMaxClockWait(50*time.Millisecond))
+	first, _ = g.NextID()
+	offset.Store(int64(-20 * time.Millisecond))
+	start := time.Now()
+	id, err := g.NextID()
+	if err != nil || id <= first {
+		t.Fatalf("Expected wait policy to recover, got %d (%v)", id, err)
+	}
+	if time.Since(start) < 15*time.Millisecond {
+		t.Errorf("Expected wait policy to block until the clock caught up")
+	}
+	offset.Store(int64(-time.Second))
+	if _, err := g.NextID(); !errors.Is(err, snow_node.ErrClockBackwards) {
+		t.Errorf("Expected ErrClockBackwards beyond max wait, got %v", err)
+	}
+}
+
+// 测试批量生成与并发唯一性
+func TestSnowNodeNextIDs(t *testing.T) {
+	g, _ := snow_node.New(1)
+	ids, err := g.NextIDs(10000)
+	if err != nil || len(ids) != 10000 {
+		t.Fatalf("Expected 10000 ids, got %d (%v)", len(ids), err)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("Expected increasing ids, got %d after %d", ids[i], ids[i-1])
+		}
+	}
+
+	seen := sync.Map{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				batch, _ := g.NextIDs(7)
+				id, _ := g.NextID()
+				for _, v := range append(batch, id) {
+					if _, dup := seen.LoadOrStore(v, true); dup {
+						t.Errorf("Duplicate id %d", v)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := snow_node.Init(1); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if s := snow_node.GetIDs(3); len(s) != 3 || s[0] == s[1] || s[1] == s[2] {
+		t.Errorf("Expected 3 distinct ids, got %v", s)
+	}
+
+	// n <= 0 时返回空切片而不 panic
+	if ids, err := g.NextIDs(-1); err != nil || len(ids) != 0 {
+		t.Errorf("Expected empty ids for n=-1, got %v (%v)", ids, err)
+	}
+	if s, err := snow_node.GetIDsE(-1); err != nil || len(s) != 0 {
+		t.Errorf("Expected empty ids for n=-1, got %v (%v)", s, err)
+	}
+	if s := snow_node.GetIDs(0); len(s) != 0 {
+		t.Errorf("Expected empty ids for n=0, got %v", s)
+	}
+}
+
+// 测试分片生成器
+func TestSnowNodeSharded(t *testing.T) {
+	if _, err := snow_node.NewSharded([]int64{1, 1}); err == nil {
+		t.Errorf("Expected error for duplicate shard node ids")
+	}
+	sg, err := snow_node.NewSharded([]int64{1, 2, 3, 4})
+	if err != nil {
+		t.Fatalf("NewSharded failed: %v", err)
+	}
+	seen := make(map[int64]bool)
+	nodes := make(map[int64]bool)
+	for i := 0; i < 20000; i++ {
+		id, err := sg.NextID()
+		if err != nil || seen[id] {
+			t.Fatalf("Expected unique id, got %d (%v)", id, err)
+		}
+		seen[id] = true
+		nodes[snow_node.Parse(id).NodeID] = true
+	}
+	if len(nodes) != 4 {
+		t.Errorf("Expected ids from 4 shards, got %d", len(nodes))
+	}
+	if ids, err := sg.NextIDs(-1); err != nil || len(ids) != 0 {
+		t.Errorf("Expected empty ids for n=-1, got %v (%v)", ids, err)
+	}
+}
@@ -0,0 +1,474 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xierui921326/toolkit/worker_pool"
+)
+
+// testJob 测试任务
+type testJob struct {
+	fn func()
+}
+
+func (j testJob) Do() {
+	j.fn()
+}
+
+// blockPool 创建一个唯一 worker 被阻塞的线程池，返回释放函数
+func blockPool(t *testing.T, opts ...worker_pool.Option) (*worker_pool.WorkerPool, func()) {
+	t.Helper()
+	wp := worker_pool.NewWorkerPool(1, opts...)
+	wp.Run()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	wp.Add(testJob{fn: func() {
+		close(started)
+		<-release
+	}})
+	<-started
+	return wp, func() { close(release) }
+}
+
+// 测试 TryAdd 默认拒绝策略，队列中最多容纳 queueSize 个任务
+func TestWorkerPoolTryAddAbort(t *testing.T) {
+	wp, release := blockPool(t, worker_pool.WithQueueSize(2))
+	defer release()
+	for i := 0; i < 2; i++ {
+		if err := wp.TryAdd(testJob{fn: func() {}}); err != nil {
+			t.Fatalf("TryAdd %d should succeed, got %v", i, err)
+		}
+	}
+	if err := wp.TryAdd(testJob{fn: func() {}}); !errors.Is(err, worker_pool.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	// 无缓冲队列在 worker 全忙时立即拒绝
+	unbuffered, releaseUnbuffered := blockPool(t)
+	defer releaseUnbuffered()
+	if err := unbuffered.TryAdd(testJob{fn: func() {}}); !errors.Is(err, worker_pool.ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull on unbuffered pool, got %v", err)
+	}
+}
+
+// 测试 CallerRunsPolicy 拒绝策略
+func TestWorkerPoolCallerRuns(t *testing.T) {
+	wp, release := blockPool(t, worker_pool.WithQueueSize(1), worker_pool.WithRejectPolicy(worker_pool.CallerRunsPolicy))
+	defer release()
+	_ = wp.TryAdd(testJob{fn: func() {}})
+	var ran bool
+	if err := wp.TryAdd(testJob{fn: func() { ran = true }}); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	if !ran {
+		t.Errorf("Expected job to run in caller goroutine")
+	}
+}
+
+// 测试 DiscardOldestPolicy 拒绝策略
+func TestWorkerPoolDiscardOldest(t *testing.T) {
+	wp, release := blockPool(t, worker_pool.WithQueueSize(1), worker_pool.WithRejectPolicy(worker_pool.DiscardOldestPolicy))
+	var oldest, newest atomic.Bool
+	_ = wp.TryAdd(testJob{fn: func() { oldest.Store(true) }})
+	done := make(chan struct{})
+	if err := wp.TryAdd(testJob{fn: func() { newest.Store(true); close(done) }}); err != nil {
+		t.Fatalf("Expected nil error, got %v", err)
+	}
+	release()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("newest job was not executed")
+	}
+	if oldest.Load() {
+		t.Errorf("Expected oldest job to be discarded")
+	}
+	if st := wp.Stats(); st.Discarded != 1 {
+		t.Errorf("Expected 1 discarded job in stats, got %d", st.Discarded)
+	}
+}
+
+// 测试 AddContext 超时
+func TestWorkerPoolAddContext(t *testing.T) {
+	wp, release := blockPool(t)
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := wp.AddContext(ctx, testJob{fn: func() {}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// waitFor 等待条件成立
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// 测试 Stats 与 StatsHandler
+func TestWorkerPoolStats(t *testing.T) {
+	wp := worker_pool.NewWorkerPool(2, worker_pool.WithQueueSize(4))
+	wp.Run()
+	defer wp.Stop()
+
+	wp.Add(testJob{fn: func() {}})
+	wp.Add(worker_pool.JobFunc(func(ctx context.Context) error { return errors.New("boom") }))
+	wp.Add(testJob{fn: func() { panic("oops") }})
+	waitFor(t, func() bool {
+		st := wp.Stats()
+		return st.Completed+st.Failed+st.Panicked == 3
+	})
+
+	st := wp.Stats()
+	if st.Completed != 1 || st.Failed != 1 || st.Panicked != 1 {
+		t.Errorf("Expected 1/1/1 completed/failed/panicked, got %d/%d/%d", st.Completed, st.Failed, st.Panicked)
+	}
+	if ls, ok := st.Latency["tests.testJob"]; !ok || ls.Count != 2 {
+		t.Errorf("Expected 2 latency samples for tests.testJob, got %+v", st.Latency)
+	}
+	if st.Workers != 2 || st.QueueCapacity != 4 {
+		t.Errorf("Unexpected pool shape %+v", st)
+	}
+
+	rec := httptest.NewRecorder()
+	wp.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	var body worker_pool.Stats
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Completed != 1 {
+		t.Errorf("Unexpected handler body %s (%v)", rec.Body.String(), err)
+	}
+}
+
+// 测试无缓冲线程池的饱和判断与按键串行通道的排队计数
+func TestWorkerPoolStatsSaturation(t *testing.T) {
+	wp, release := blockPool(t)
+	if st := wp.Stats(); st.Saturated {
+		t.Errorf("Expected busy unbuffered pool without waiters not to be saturated, got %+v", st)
+	}
+	added := make(chan struct{})
+	go func() {
+		wp.Add(testJob{fn: func() {}})
+		close(added)
+	}()
+	waitFor(t, func() bool { return wp.Stats().Waiting == 1 })
+	if st := wp.Stats(); !st.Saturated {
+		t.Errorf("Expected unbuffered pool with a waiting caller to be saturated, got %+v", st)
+	}
+	release()
+	<-added
+	wp.Stop()
+
+	keyed := worker_pool.NewWorkerPool(1, worker_pool.WithQueueSize(4), worker_pool.WithKeyedLanes(1))
+	keyed.Run()
+	defer keyed.Stop()
+	block := make(chan struct{})
+	started := make(chan struct{})
+	_ = keyed.AddKeyed("k", testJob{fn: func() {
+		close(started)
+		<-block
+	}})
+	<-started
+	defer close(block)
+	_ = keyed.AddKeyed("k", testJob{fn: func() {}})
+	_ = keyed.AddKeyed("k", testJob{fn: func() {}})
+	if st := keyed.Stats(); st.Queued != 2 {
+		t.Errorf("Expected 2 queued keyed jobs, got %d", st.Queued)
+	}
+}
+
+// 测试重试策略与死信回调
+func TestWorkerPoolRetry(t *testing.T) {
+	errTemporary := errors.New("temporary")
+	errFatal := errors.New("fatal")
+	dead := make(chan error, 2)
+	wp := worker_pool.NewWorkerPool(1, worker_pool.WithQueueSize(4), worker_pool.WithRetryPolicy(worker_pool.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Jitter:         0.5,
+		AttemptTimeout: 50 * time.Millisecond,
+		Retryable:      func(err error) bool { return !errors.Is(err, errFatal) },
+		DeadLetter:     func(job worker_pool.Job, err error) { dead <- err },
+	}))
+	wp.Run()
+	defer wp.Stop()
+
+	var attempts atomic.Int32
+	wp.Add(worker_pool.JobFunc(func(ctx context.Context) error {
+		if attempts.Add(1) < 3 {
+			return errTemporary
+		}
+		return nil
+	}))
+	wp.Add(worker_pool.JobFunc(func(ctx context.Context) error { return errFatal }))
+	wp.Add(worker_pool.JobFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	for _, want := range []error{errFatal, context.DeadlineExceeded} {
+		select {
+		case err := <-dead:
+			if !errors.Is(err, want) {
+				t.Errorf("Expected dead letter error %v, got %v", want, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("dead letter callback not called")
+		}
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+	st := wp.Stats()
+	if st.Completed != 1 || st.DeadLettered != 2 || st.Retried != 4 {
+		t.Errorf("Unexpected stats %+v", st)
+	}
+}
+
+// 测试按键串行执行
+func TestWorkerPoolKeyed(t *testing.T) {
+	wp := worker_pool.NewWorkerPool(1, worker_pool.WithQueueSize(8), worker_pool.WithKeyedLanes(4))
+	wp.Run()
+	defer wp.Stop()
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		key := "user-" + strconv.Itoa(i%5)
+		seq := i
+		wg.Add(1)
+		err := wp.AddKeyed(key, testJob{fn: func() {
+			defer wg.Done()
+			mu.Lock()
+			seen[key] = append(seen[key], seq)
+			mu.Unlock()
+		}})
+		if err != nil {
+			t.Fatalf("AddKeyed failed: %v", err)
+		}
+	}
+	wg.Wait()
+	for key, seqs := range seen {
+		for i := 1; i < len(seqs); i++ {
+			if seqs[i] < seqs[i-1] {
+				t.Errorf("Jobs for %s ran out of order: %v", key, seqs)
+				break
+			}
+		}
+	}
+
+	plain := worker_pool.NewWorkerPool(1)
+	if err := plain.AddKeyed("k", testJob{fn: func() {}}); !errors.Is(err, worker_pool.ErrKeyedDisabled) {
+		t.Errorf("Expected ErrKeyedDisabled, got %v", err)
+	}
+}
+
+// weightedJob 测试加权任务
+type weightedJob struct {
+	testJob
+	weight int64
+}
+
+func (j weightedJob) Weight() int64 {
+	return j.weight
+}
+
+// 测试速率限制
+func TestWorkerPoolRateLimit(t *testing.T) {
+	wp := worker_pool.NewWorkerPool(4, worker_pool.WithQueueSize(8), worker_pool.WithRateLimit(50, 1))
+	wp.Run()
+	defer wp.Stop()
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		wp.Add(testJob{fn: wg.Done})
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected rate limited execution to take at least 80ms, took %v", elapsed)
+	}
+
+	// 令牌桶初始为满，burst 个任务可立即执行
+	burst := worker_pool.NewWorkerPool(4, worker_pool.WithQueueSize(8), worker_pool.WithRateLimit(1, 3))
+	burst.Run()
+	defer burst.Stop()
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		burst.Add(testJob{fn: wg.Done})
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected initial burst to run immediately, took %v", elapsed)
+	}
+
+	wp.SetRateLimit(0, 0)
+	start = time.Now()
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		wp.Add(testJob{fn: wg.Done})
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+		t.Errorf("Expected unlimited execution to be fast, took %v", elapsed)
+	}
+}
+
+// 测试加权信号量
+func TestWorkerPoolWeightedSlots(t *testing.T) {
+	wp := worker_pool.NewWorkerPool(4, worker_pool.WithQueueSize(16), worker_pool.WithWeightedSlots(3))
+	wp.Run()
+	defer wp.Stop()
+
+	var used, peak atomic.Int64
+	var wg sync.WaitGroup
+	run := func(weight int64) func() {
+		return func() {
+			defer wg.Done()
+			cur := used.Add(weight)
+			for {
+				p := peak.Load()
+				if cur <= p || peak.CompareAndSwap(p, cur) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			used.Add(-weight)
+		}
+	}
+	for i := 0; i < 12; i++ {
+		weight := int64(1 + i%3)
+		wg.Add(1)
+		wp.Add(weightedJob{testJob: testJob{fn: run(weight)}, weight: weight})
+	}
+	wg.Wait()
+	if peak.Load() > 3 {
+		t.Errorf("Expected at most 3 slots in use, peak was %d", peak.Load())
+	}
+
+	// 等待槽位的任务由调度协程持有，不占用其他 worker
+	wp.SetWeightedSlots(1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	wp.Add(testJob{fn: func() {
+		close(started)
+		<-release
+	}})
+	<-started
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		wp.Add(testJob{fn: wg.Done})
+	}
+	time.Sleep(20 * time.Millisecond)
+	if st := wp.Stats(); st.Active != 1 {
+		t.Errorf("Expected only the slot holder to be active, got %d", st.Active)
+	}
+	close(release)
+	wg.Wait()
+}
+
+// 测试租户公平调度与租户内优先级
+func TestWorkerPoolTenantFairness(t *testing.T) {
+	wp := worker_pool.NewWorkerPool(1, worker_pool.WithTenantWeight("gold", 2))
+	wp.Run()
+	defer wp.Stop()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	wp.Add(testJob{fn: func() {
+		close(started)
+		<-release
+	}})
+	<-started
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	add := func(tenant string, priority int, name string) {
+		wg.Add(1)
+		err := wp.AddTenant(tenant, priority, testJob{fn: func() {
+			defer wg.Done()
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}})
+		if err != nil {
+			t.Fatalf("AddTenant failed: %v", err)
+		}
+	}
+	add("noisy", 0, "n0")
+	add("noisy", 5, "n1")
+	add("noisy", 0, "n2")
+	add("noisy", 9, "n3")
+	add("noisy", 0, "n4")
+	add("noisy", 0, "n5")
+	add("quiet", 0, "q0")
+	add("quiet", 1, "q1")
+	add("gold", 0, "g0")
+	add("gold", 0, "g1")
+	add("gold", 0, "g2")
+	add("gold", 0, "g3")
+	if st := wp.Stats(); st.Queued != 12 {
+		t.Errorf("Expected 12 queued jobs, got %d", st.Queued)
+	}
+	close(release)
+	wg.Wait()
+
+	want := []string{"g0", "n3", "q1", "g1", "g2", "n1", "q0", "g3", "n0", "n2", "n4", "n5"}
+	if len(order) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Expected %v, got %v", want, order)
+		}
+	}
+}
+
+// 测试停止后租户队列中的任务被丢弃并计数
+func TestWorkerPoolTenantStop(t *testing.T) {
+	wp := worker_pool.NewWorkerPool(1)
+	wp.Run()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	wp.Add(testJob{fn: func() {
+		close(started)
+		<-release
+	}})
+	<-started
+	defer close(release)
+
+	var ran atomic.Int32
+	for i := 0; i < 3; i++ {
+		if err := wp.AddTenant("t", 0, testJob{fn: func() { ran.Add(1) }}); err != nil {
+			t.Fatalf("AddTenant failed: %v", err)
+		}
+	}
+	wp.Stop()
+	waitFor(t, func() bool { return wp.Stats().Failed == 3 })
+	if err := wp.AddTenant("t", 0, testJob{fn: func() {}}); !errors.Is(err, worker_pool.ErrPoolStopped) {
+		t.Errorf("Expected ErrPoolStopped, got %v", err)
+	}
+	if ran.Load() != 0 {
+		t.Errorf("Expected no tenant job to run after Stop, %d ran", ran.Load())
+	}
+}
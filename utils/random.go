@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// RandomBytes 读取随机字节
+//
+// @Description: 从 crypto/rand 读取随机字节填满 b
+// @param b 目标切片
+// @return error 读取系统随机数失败
+func RandomBytes(b []byte) error {
+	_, err := io.ReadFull(rand.Reader, b)
+	return err
+}
+
+// RandomHex 生成十六进制随机串
+//
+// @Description: 从 crypto/rand 读取 n 字节并转为 2n 位十六进制
+// @param n 随机字节数
+// @return string 十六进制随机串
+// @return error 读取系统随机数失败
+func RandomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if err := RandomBytes(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RandomString 从字母表中均匀选取字符生成随机串
+//
+// @Description: 使用 crypto/rand 与掩码拒绝采样，避免取模带来的偏差，每个字符等概率出现
+// @param alphabet 字母表，长度 1~256
+// @param length 随机串长度
+// @return string 随机串
+// @return error 字母表不合法或读取系统随机数失败
+func RandomString(alphabet string, length int) (string, error) {
+	if len(alphabet) == 0 || len(alphabet) > 256 {
+		return "", errors.New("utils: alphabet must contain 1 to 256 characters")
+	}
+	mask := byte(1<<bits.Len(uint(len(alphabet)-1)) - 1)
+	out := make([]byte, 0, length)
+	// 按拒绝率估算每批需要的随机字节数
+	buf := make([]byte, 8*length/5+1)
+	for len(out) < length {
+		if err := RandomBytes(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if i := int(b & mask); i < len(alphabet) {
+				out = append(out, alphabet[i])
+				if len(out) == length {
+					break
+				}
+			}
+		}
+	}
+	return string(out), nil
+}
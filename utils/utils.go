@@ -24,23 +24,43 @@ import (
 // @params params 请求参数字典
 // @return string 排序后的请求参数字符串
 func SortedQueryString(params map[string]string) string {
+	values := make(url.Values, len(params))
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return SortedQueryValues(values)
+}
+
+// SortedQueryValues 多值请求参数排序
+//
+// @description 按键排序请求参数，同名参数按值排序后重复出现，返回排序后的请求参数字符串(不包含签名参数)，
+// 转义方式与 SortedQueryString 一致
+// @params params 请求参数
+// @return string 排序后的请求参数字符串
+func SortedQueryValues(params url.Values) string {
 	keys := make([]string, 0, len(params))
-	for k, _ := range params {
+	for k := range params {
 		if strings.EqualFold(k, "signature") {
 			continue
 		}
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
+	escape := func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	}
 	var sortedQuery strings.Builder
-	for i, each := range keys {
-		if i > 0 {
-			sortedQuery.WriteByte('&')
+	for _, each := range keys {
+		values := append([]string(nil), params[each]...)
+		sort.Strings(values)
+		for _, v := range values {
+			if sortedQuery.Len() > 0 {
+				sortedQuery.WriteByte('&')
+			}
+			sortedQuery.WriteString(escape(each))
+			sortedQuery.WriteByte('=')
+			sortedQuery.WriteString(escape(v))
 		}
-		sortedQuery.WriteString(strings.ReplaceAll(url.QueryEscape(each),
-			"+", "%20"))
-		sortedQuery.WriteByte('=')
-		sortedQuery.WriteString(strings.ReplaceAll(url.QueryEscape(params[each]), "+", "%20"))
 	}
 	return sortedQuery.String()
 }
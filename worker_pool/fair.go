@@ -0,0 +1,180 @@
+package worker_pool
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// WithTenantWeight 设置租户权重
+//
+// @Description: 设置租户在加权公平调度中的权重，默认1，权重越大获得的 worker 份额越多
+// @param tenant 租户
+// @param weight 权重，小于等于0时按1处理
+// @return Option
+func WithTenantWeight(tenant string, weight float64) Option {
+	return func(wp *WorkerPool) {
+		wp.fair.setWeight(tenant, weight)
+	}
+}
+
+// SetTenantWeight 运行时调整租户权重
+//
+// @Description: 运行时调整租户权重，小于等于0时按1处理
+// @param tenant 租户
+// @param weight 权重
+func (wp *WorkerPool) SetTenantWeight(tenant string, weight float64) {
+	wp.fair.setWeight(tenant, weight)
+}
+
+// AddTenant 按租户和优先级添加任务
+//
+// @Description: 不同租户之间按权重公平调度，同一租户内按优先级严格调度(数值越大越优先，相同优先级先进先出)。
+// 设置了 WithQueueSize 时，租户队列中的任务总数不超过队列容量
+// @param tenant 租户
+// @param priority 优先级
+// @param job 任务
+// @return error 队列已满时返回 ErrQueueFull，线程池已停止时返回 ErrPoolStopped
+func (wp *WorkerPool) AddTenant(tenant string, priority int, job Job) error {
+	return wp.fair.push(tenant, priority, job, wp.queueSize)
+}
+
+// tenantJob 租户任务
+type tenantJob struct {
+	job      Job
+	priority int
+	seq      uint64
+}
+
+// jobHeap 按优先级(高优先)、提交顺序排序的任务堆
+type jobHeap []tenantJob
+
+func (h jobHeap) Len() int { return len(h) }
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x any)   { *h = append(*h, x.(tenantJob)) }
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = tenantJob{}
+	*h = old[:n-1]
+	return item
+}
+
+// tenantQueue 单个租户的任务队列
+type tenantQueue struct {
+	vtime float64 //虚拟时间，每调度一个任务增加 1/权重
+	jobs  jobHeap
+}
+
+// fairQueue 加权公平队列
+//
+// 每个租户维护虚拟时间，调度时选择虚拟时间最小的租户；
+// 租户从空闲变为有任务时，虚拟时间追平全局虚拟时间，避免积累额度后独占 worker
+type fairQueue struct {
+	mu      sync.Mutex
+	ready   chan struct{}           //入队通知 由调度协程消费
+	tenants map[string]*tenantQueue //有待调度任务的租户
+	weights map[string]float64
+	vtime   float64 //全局虚拟时间
+	size    int
+	seq     uint64
+	closed  bool //线程池已停止 不再接受任务
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{
+		ready:   make(chan struct{}, 1),
+		tenants: make(map[string]*tenantQueue),
+		weights: make(map[string]float64),
+	}
+}
+
+// setWeight 设置租户权重
+func (fq *fairQueue) setWeight(tenant string, weight float64) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	if weight <= 0 {
+		delete(fq.weights, tenant)
+		return
+	}
+	fq.weights[tenant] = weight
+}
+
+// push 入队，limit 大于0且队列已满时返回 ErrQueueFull
+func (fq *fairQueue) push(tenant string, priority int, job Job, limit int) error {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	if fq.closed {
+		return ErrPoolStopped
+	}
+	if limit > 0 && fq.size >= limit {
+		return ErrQueueFull
+	}
+	tq, ok := fq.tenants[tenant]
+	if !ok {
+		tq = &tenantQueue{vtime: fq.vtime}
+		fq.tenants[tenant] = tq
+	}
+	heap.Push(&tq.jobs, tenantJob{job: job, priority: priority, seq: fq.seq})
+	fq.seq++
+	fq.size++
+	select {
+	case fq.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop 取出虚拟时间最小的租户中优先级最高的任务
+func (fq *fairQueue) pop() (Job, bool) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	var (
+		name string
+		next *tenantQueue
+	)
+	for tenant, tq := range fq.tenants {
+		if next == nil || tq.vtime < next.vtime || (tq.vtime == next.vtime && tenant < name) {
+			name, next = tenant, tq
+		}
+	}
+	if next == nil {
+		return nil, false
+	}
+	tj := heap.Pop(&next.jobs).(tenantJob)
+	fq.size--
+	fq.vtime = next.vtime
+	weight, ok := fq.weights[name]
+	if !ok {
+		weight = 1
+	}
+	next.vtime += 1 / weight
+	if next.jobs.Len() == 0 {
+		delete(fq.tenants, name)
+	}
+	return tj.job, true
+}
+
+// close 停止接受任务并清空队列，返回被丢弃的任务数
+func (fq *fairQueue) close() int {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	fq.closed = true
+	dropped := fq.size
+	fq.tenants = make(map[string]*tenantQueue)
+	fq.size = 0
+	return dropped
+}
+
+// len 队列中的任务数
+func (fq *fairQueue) len() int {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	return fq.size
+}
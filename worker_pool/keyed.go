@@ -0,0 +1,156 @@
+package worker_pool
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+)
+
+// ErrKeyedDisabled 线程池未开启按键串行模式
+var ErrKeyedDisabled = errors.New("worker_pool: keyed dispatch is not enabled")
+
+// WithKeyedLanes 开启按键串行模式
+//
+// @Description: 开启按键串行模式，相同 key 的任务按提交顺序在同一条通道(lane)上串行执行，
+// 不同 key 的任务在多条通道上并行执行。lane 独立于普通 worker，队列容量与 WithQueueSize 一致
+// @param lanes 通道数量
+// @return Option
+func WithKeyedLanes(lanes int) Option {
+	return func(wp *WorkerPool) {
+		if lanes > 0 {
+			wp.laneLen = lanes
+		}
+	}
+}
+
+// keyedJob 带 key 的任务
+type keyedJob struct {
+	key string
+	job Job
+}
+
+// lane 串行执行通道
+type lane struct {
+	jobs chan keyedJob
+	load int //已分配但未完成的任务数
+}
+
+// keyRef key 当前绑定的通道
+type keyRef struct {
+	lane    int
+	pending int
+}
+
+// keyedDispatcher 按键分配通道
+//
+// key 有未完成的任务时固定在同一条通道上以保证顺序；
+// 空闲的 key 在两个哈希候选通道中选择负载较低的一条，使负载在通道间保持均衡
+type keyedDispatcher struct {
+	mu    sync.Mutex
+	lanes []*lane
+	keys  map[string]*keyRef
+}
+
+func newKeyedDispatcher(lanes, queueSize int) *keyedDispatcher {
+	d := &keyedDispatcher{
+		lanes: make([]*lane, lanes),
+		keys:  make(map[string]*keyRef),
+	}
+	for i := range d.lanes {
+		d.lanes[i] = &lane{jobs: make(chan keyedJob, queueSize)}
+	}
+	return d
+}
+
+// assign 为 key 分配通道
+func (d *keyedDispatcher) assign(key string) *lane {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ref, ok := d.keys[key]
+	if !ok {
+		ref = &keyRef{lane: d.pick(key)}
+		d.keys[key] = ref
+	}
+	ref.pending++
+	l := d.lanes[ref.lane]
+	l.load++
+	return l
+}
+
+// pick 在两个哈希候选通道中选择负载较低的一条
+func (d *keyedDispatcher) pick(key string) int {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	n := uint64(len(d.lanes))
+	first := int(sum % n)
+	second := int((sum >> 32) % n)
+	if d.lanes[second].load < d.lanes[first].load {
+		return second
+	}
+	return first
+}
+
+// done 任务完成(或提交失败)后释放 key 的占用
+func (d *keyedDispatcher) done(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ref, ok := d.keys[key]
+	if !ok {
+		return
+	}
+	d.lanes[ref.lane].load--
+	ref.pending--
+	if ref.pending <= 0 {
+		delete(d.keys, key)
+	}
+}
+
+// runLanes 启动所有通道协程
+func (wp *WorkerPool) runLanes() {
+	for _, l := range wp.keyed.lanes {
+		go func(l *lane) {
+			for {
+				select {
+				case kj := <-l.jobs:
+					wp.runWorkerJob(kj.job)
+					wp.keyed.done(kj.key)
+				case <-wp.ctx.Done():
+					return
+				}
+			}
+		}(l)
+	}
+}
+
+// AddKeyed 按键添加任务
+//
+// @Description: 相同 key 的任务按提交顺序串行执行，通道队列已满时阻塞等待
+// @param key 任务键，如用户 ID
+// @param job 任务
+// @return error 未开启按键串行模式时返回 ErrKeyedDisabled
+func (wp *WorkerPool) AddKeyed(key string, job Job) error {
+	return wp.AddKeyedContext(context.Background(), key, job)
+}
+
+// AddKeyedContext 按键添加任务，直到入队成功或 ctx 结束
+//
+// @Description: 相同 key 的任务按提交顺序串行执行，ctx 取消或超时时返回 ctx.Err()
+// @param ctx 上下文
+// @param key 任务键，如用户 ID
+// @param job 任务
+// @return error
+func (wp *WorkerPool) AddKeyedContext(ctx context.Context, key string, job Job) error {
+	if wp.keyed == nil {
+		return ErrKeyedDisabled
+	}
+	l := wp.keyed.assign(key)
+	select {
+	case l.jobs <- keyedJob{key: key, job: job}:
+		return nil
+	case <-ctx.Done():
+		wp.keyed.done(key)
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,240 @@
+package worker_pool
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// WeightedJob 声明权重的任务，开启加权信号量后占用 Weight() 个执行槽位
+type WeightedJob interface {
+	Job
+	Weight() int64
+}
+
+// WithRateLimit 设置任务执行速率限制(令牌桶)
+//
+// @Description: 设置每秒最多开始执行的任务数，qps 小于等于0表示不限速
+// @param qps 每秒令牌数
+// @param burst 令牌桶容量，小于1时按1处理
+// @return Option
+func WithRateLimit(qps float64, burst int) Option {
+	return func(wp *WorkerPool) {
+		wp.limiter.setRate(qps, burst)
+	}
+}
+
+// WithWeightedSlots 设置加权信号量的槽位总数
+//
+// @Description: 开启加权信号量，任务执行前需占用 Weight() 个槽位，slots 小于等于0表示不限制
+// @param slots 槽位总数
+// @return Option
+func WithWeightedSlots(slots int64) Option {
+	return func(wp *WorkerPool) {
+		wp.semaphore.resize(slots)
+	}
+}
+
+// SetRateLimit 运行时调整速率限制
+//
+// @Description: 运行时调整速率限制，qps 小于等于0表示不限速
+// @param qps 每秒令牌数
+// @param burst 令牌桶容量
+func (wp *WorkerPool) SetRateLimit(qps float64, burst int) {
+	wp.limiter.setRate(qps, burst)
+}
+
+// SetWeightedSlots 运行时调整加权信号量的槽位总数
+//
+// @Description: 运行时调整槽位总数，slots 小于等于0表示不限制
+// @param slots 槽位总数
+func (wp *WorkerPool) SetWeightedSlots(slots int64) {
+	wp.semaphore.resize(slots)
+}
+
+// acquire 等待速率令牌与信号量槽位，返回释放函数
+//
+// 调度协程在把任务交给 worker 之前调用，等待中的任务不会占住其他 worker；
+// 按键串行通道与 CallerRunsPolicy 在执行前自行调用
+func (wp *WorkerPool) acquire(job Job) (func(), error) {
+	if err := wp.limiter.wait(wp.ctx); err != nil {
+		return nil, err
+	}
+	weight := int64(1)
+	if wj, ok := job.(WeightedJob); ok && wj.Weight() > 0 {
+		weight = wj.Weight()
+	}
+	return wp.semaphore.acquire(wp.ctx, weight)
+}
+
+// boundJob 调度协程已取得令牌与槽位的任务，执行完成后调用 release 归还
+type boundJob struct {
+	job     Job
+	release func()
+}
+
+func (j boundJob) Do() {
+	j.job.Do()
+}
+
+// rateLimiter 令牌桶限速器
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 //每秒令牌数 小于等于0表示不限速
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// setRate 调整速率
+func (l *rateLimiter) setRate(qps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if burst < 1 {
+		burst = 1
+	}
+	if l.last.IsZero() {
+		//首次设置时令牌桶是满的，允许立即突发 burst 个任务
+		l.tokens = float64(burst)
+	}
+	l.refill(now)
+	l.rate = qps
+	l.burst = float64(burst)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// refill 按时间补充令牌
+func (l *rateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() && l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// wait 预占一个令牌，令牌不足时等待
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	l.refill(time.Now())
+	l.tokens--
+	if l.tokens >= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++ //归还预占的令牌
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// semWaiter 信号量等待者
+type semWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// weightedSemaphore 先进先出的加权信号量
+type weightedSemaphore struct {
+	mu      sync.Mutex
+	size    int64 //槽位总数 小于等于0表示不限制
+	cur     int64
+	waiters list.List
+}
+
+// acquire 占用 n 个槽位，n 超过槽位总数时按总数计算
+func (s *weightedSemaphore) acquire(ctx context.Context, n int64) (func(), error) {
+	s.mu.Lock()
+	if s.size <= 0 {
+		s.mu.Unlock()
+		return func() {}, nil
+	}
+	if n > s.size {
+		n = s.size
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return func() { s.release(n) }, nil
+	}
+	w := &semWaiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return func() { s.release(w.n) }, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			//已经获得槽位，直接归还
+			s.cur -= w.n
+		default:
+			s.waiters.Remove(elem)
+		}
+		s.notify()
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// release 归还 n 个槽位
+func (s *weightedSemaphore) release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	s.notify()
+}
+
+// resize 调整槽位总数
+func (s *weightedSemaphore) resize(size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = size
+	s.notify()
+}
+
+// notify 按先进先出顺序唤醒槽位足够的等待者，调用方需持有锁
+func (s *weightedSemaphore) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*semWaiter)
+		if s.size <= 0 {
+			//已关闭限制，唤醒所有等待者且不计占用
+			w.n = 0
+		} else {
+			if w.n > s.size {
+				w.n = s.size
+			}
+			if s.size-s.cur < w.n {
+				return
+			}
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,30 @@
+package worker_pool
+
+// Option 线程池可选配置
+type Option func(wp *WorkerPool)
+
+// WithQueueSize 设置任务队列容量
+//
+// @Description: 设置任务队列容量，0 表示无缓冲(Add 会阻塞直到有 worker 接收)
+// @param size 队列容量
+// @return Option
+func WithQueueSize(size int) Option {
+	return func(wp *WorkerPool) {
+		if size > 0 {
+			wp.queueSize = size
+		}
+	}
+}
+
+// WithRejectPolicy 设置队列已满时的拒绝策略
+//
+// @Description: 设置 TryAdd 在队列已满时的拒绝策略，默认 AbortPolicy
+// @param policy 拒绝策略
+// @return Option
+func WithRejectPolicy(policy RejectPolicy) Option {
+	return func(wp *WorkerPool) {
+		if policy != nil {
+			wp.rejectPolicy = policy
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package worker_pool
+
+// RejectPolicy 拒绝策略
+//
+// @Description: 任务队列已满时 TryAdd 调用的处理函数，返回值会作为 TryAdd 的返回值
+// @param wp 线程池
+// @param job 被拒绝的任务
+// @return error
+type RejectPolicy func(wp *WorkerPool, job Job) error
+
+// AbortPolicy 直接拒绝，返回 ErrQueueFull
+func AbortPolicy(wp *WorkerPool, job Job) error {
+	return ErrQueueFull
+}
+
+// CallerRunsPolicy 由调用方协程直接执行任务
+func CallerRunsPolicy(wp *WorkerPool, job Job) error {
+	wp.execute(job)
+	return nil
+}
+
+// DiscardPolicy 丢弃当前任务，返回 ErrJobDiscarded
+func DiscardPolicy(wp *WorkerPool, job Job) error {
+	wp.stats.discarded.Add(1)
+	return ErrJobDiscarded
+}
+
+// DiscardOldestPolicy 丢弃队列中最早的任务，再尝试将当前任务入队
+//
+// @Description: 被丢弃的任务计入 Stats().Discarded；无缓冲队列中没有可丢弃的任务，此时返回 ErrQueueFull
+func DiscardOldestPolicy(wp *WorkerPool, job Job) error {
+	if cap(wp.JobQueue) == 0 {
+		return ErrQueueFull
+	}
+	for {
+		select {
+		case <-wp.JobQueue: //丢弃最早的任务
+			wp.stats.discarded.Add(1)
+		default:
+		}
+		if wp.offer(job) {
+			return nil
+		}
+	}
+}
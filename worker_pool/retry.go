@@ -0,0 +1,125 @@
+package worker_pool
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy 任务重试策略
+//
+// @Description: 仅对 ContextJob 生效，DoContext 返回错误时按策略重试
+type RetryPolicy struct {
+	MaxAttempts    int                      //最大尝试次数(含首次)，小于等于1表示不重试
+	InitialBackoff time.Duration            //首次重试前的等待时间
+	MaxBackoff     time.Duration            //最大等待时间，0 表示不限制
+	Multiplier     float64                  //退避倍数，小于1时按2处理
+	Jitter         float64                  //随机抖动比例 0~1，等待时间在 [d*(1-Jitter), d] 内随机
+	AttemptTimeout time.Duration            //单次尝试超时，0 表示不限制
+	Retryable      func(err error) bool     //判断错误是否可重试，为空时所有错误都重试
+	DeadLetter     func(job Job, err error) //最终失败(重试耗尽或不可重试)的任务回调
+}
+
+// RetryableJob 自带重试策略的任务，优先于线程池的 WithRetryPolicy
+type RetryableJob interface {
+	ContextJob
+	RetryPolicy() RetryPolicy
+}
+
+// WithRetryPolicy 设置线程池默认的重试策略
+//
+// @Description: 设置线程池默认的重试策略
+// @param policy 重试策略
+// @return Option
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(wp *WorkerPool) {
+		wp.retryPolicy = &policy
+	}
+}
+
+// backoff 计算第 attempt 次重试前的等待时间，attempt 从1开始
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// retryable 判断错误是否可重试
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable == nil {
+		return true
+	}
+	return p.Retryable(err)
+}
+
+// policyFor 获取任务对应的重试策略，没有则返回 nil
+func (wp *WorkerPool) policyFor(job ContextJob) *RetryPolicy {
+	if rj, ok := job.(RetryableJob); ok {
+		p := rj.RetryPolicy()
+		return &p
+	}
+	return wp.retryPolicy
+}
+
+// runWithRetry 按重试策略执行任务，返回最后一次尝试的错误
+func (wp *WorkerPool) runWithRetry(job ContextJob) error {
+	policy := wp.policyFor(job)
+	if policy == nil {
+		return job.DoContext(wp.ctx)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = wp.attempt(job, policy)
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			break
+		}
+		if waitErr := wp.sleep(policy.backoff(attempt)); waitErr != nil {
+			err = waitErr
+			break
+		}
+		wp.stats.retried.Add(1)
+	}
+
+	if policy.DeadLetter != nil {
+		wp.stats.dead.Add(1)
+		policy.DeadLetter(job, err)
+	}
+	return err
+}
+
+// attempt 执行一次尝试，按策略设置超时
+func (wp *WorkerPool) attempt(job ContextJob, policy *RetryPolicy) error {
+	if policy.AttemptTimeout <= 0 {
+		return job.DoContext(wp.ctx)
+	}
+	ctx, cancel := context.WithTimeout(wp.ctx, policy.AttemptTimeout)
+	defer cancel()
+	return job.DoContext(ctx)
+}
+
+// sleep 等待指定时间，线程池停止时提前返回
+func (wp *WorkerPool) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-wp.ctx.Done():
+		return wp.ctx.Err()
+	}
+}
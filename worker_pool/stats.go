@@ -0,0 +1,192 @@
+package worker_pool
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyWindow 每种任务类型保留的最近耗时样本数
+const latencyWindow = 1024
+
+// outcome 任务执行结果
+type outcome int
+
+const (
+	outcomeCompleted outcome = iota
+	outcomeFailed
+	outcomePanicked
+)
+
+// LatencyStats 某类任务最近执行耗时的分位数
+type LatencyStats struct {
+	Count int64         `json:"count"` //累计执行次数
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"` //采样窗口内的最大耗时
+}
+
+// Stats 线程池统计快照
+type Stats struct {
+	Workers       int                     `json:"workers"`        //worker 数量(含按键串行通道)
+	Active        int                     `json:"active"`         //正在执行任务的 worker 数
+	Idle          int                     `json:"idle"`           //空闲 worker 数
+	Queued        int                     `json:"queued"`         //排队中的任务数(含租户队列与按键串行通道)
+	QueueCapacity int                     `json:"queue_capacity"` //队列容量 0表示无缓冲
+	Waiting       int                     `json:"waiting"`        //阻塞在 Add/AddContext 中等待入队的调用方数
+	Completed     int64                   `json:"completed"`      //成功完成的任务数
+	Failed        int64                   `json:"failed"`         //返回错误的任务数
+	Panicked      int64                   `json:"panicked"`       //发生 panic 的任务数
+	Retried       int64                   `json:"retried"`        //重试次数
+	DeadLettered  int64                   `json:"dead_lettered"`  //进入死信回调的任务数
+	Discarded     int64                   `json:"discarded"`      //被 DiscardPolicy、DiscardOldestPolicy 丢弃的任务数
+	Saturated     bool                    `json:"saturated"`      //worker 全忙且队列已满(无缓冲时为有调用方等待入队)
+	Latency       map[string]LatencyStats `json:"latency"`        //按任务类型统计的耗时，单位纳秒
+}
+
+// latencySamples 环形耗时样本
+type latencySamples struct {
+	count   int64
+	samples []time.Duration
+	next    int
+}
+
+// poolStats 线程池运行统计
+type poolStats struct {
+	active    atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	panicked  atomic.Int64
+	retried   atomic.Int64
+	dead      atomic.Int64
+	discarded atomic.Int64
+	waiting   atomic.Int64 //阻塞等待入队的调用方数
+
+	mu      sync.Mutex
+	latency map[string]*latencySamples
+}
+
+func newPoolStats() *poolStats {
+	return &poolStats{latency: make(map[string]*latencySamples)}
+}
+
+// record 记录一次任务执行
+func (s *poolStats) record(typ string, d time.Duration, o outcome) {
+	switch o {
+	case outcomeCompleted:
+		s.completed.Add(1)
+	case outcomeFailed:
+		s.failed.Add(1)
+	case outcomePanicked:
+		s.panicked.Add(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ls, ok := s.latency[typ]
+	if !ok {
+		ls = &latencySamples{samples: make([]time.Duration, 0, latencyWindow)}
+		s.latency[typ] = ls
+	}
+	ls.count++
+	if len(ls.samples) < latencyWindow {
+		ls.samples = append(ls.samples, d)
+		return
+	}
+	ls.samples[ls.next] = d
+	ls.next = (ls.next + 1) % latencyWindow
+}
+
+// snapshot 计算各类型任务的耗时分位数
+func (s *poolStats) snapshot() map[string]LatencyStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]LatencyStats, len(s.latency))
+	for typ, ls := range s.latency {
+		sorted := make([]time.Duration, len(ls.samples))
+		copy(sorted, ls.samples)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		result[typ] = LatencyStats{
+			Count: ls.count,
+			P50:   percentile(sorted, 0.50),
+			P90:   percentile(sorted, 0.90),
+			P99:   percentile(sorted, 0.99),
+			Max:   percentile(sorted, 1),
+		}
+	}
+	return result
+}
+
+// percentile 取已排序样本的分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// Stats 获取线程池统计快照
+//
+// @Description: 获取 worker、队列、任务结果计数以及按任务类型统计的耗时分位数
+// @return Stats 统计快照
+func (wp *WorkerPool) Stats() Stats {
+	active := int(wp.stats.active.Load())
+	workers := wp.workerLen + wp.laneLen
+	queued := len(wp.JobQueue) + wp.fair.len()
+	if wp.keyed != nil {
+		for _, l := range wp.keyed.lanes {
+			queued += len(l.jobs)
+		}
+	}
+	st := Stats{
+		Workers:       workers,
+		Active:        active,
+		Idle:          workers - active,
+		Queued:        queued,
+		QueueCapacity: cap(wp.JobQueue),
+		Waiting:       int(wp.stats.waiting.Load()),
+		Completed:     wp.stats.completed.Load(),
+		Failed:        wp.stats.failed.Load(),
+		Panicked:      wp.stats.panicked.Load(),
+		Retried:       wp.stats.retried.Load(),
+		DeadLettered:  wp.stats.dead.Load(),
+		Discarded:     wp.stats.discarded.Load(),
+		Latency:       wp.stats.snapshot(),
+	}
+	if st.Idle < 0 {
+		st.Idle = 0
+	}
+	if st.QueueCapacity == 0 {
+		//无缓冲队列没有排队空间，只有调用方阻塞等待入队时才算饱和
+		st.Saturated = st.Idle == 0 && st.Waiting > 0
+	} else {
+		st.Saturated = st.Idle == 0 && len(wp.JobQueue) >= st.QueueCapacity
+	}
+	return st
+}
+
+// StatsHandler 线程池统计与健康检查接口
+//
+// @Description: 以 JSON 输出 Stats()，线程池饱和时返回 503 状态码
+// @return http.Handler
+func (wp *WorkerPool) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st := wp.Stats()
+		w.Header().Set("Content-Type", "application/json")
+		if st.Saturated {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(st)
+	})
+}
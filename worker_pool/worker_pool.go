@@ -1,14 +1,59 @@
 package worker_pool
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	// ErrQueueFull 任务队列已满
+	ErrQueueFull = errors.New("worker_pool: job queue is full")
+	// ErrJobDiscarded 任务因队列已满被丢弃
+	ErrJobDiscarded = errors.New("worker_pool: job discarded")
+	// ErrPoolStopped 线程池已停止
+	ErrPoolStopped = errors.New("worker_pool: pool is stopped")
+)
+
 // Job 任务
 type Job interface {
 	Do() // 执行任务...
 }
 
+// ContextJob 支持上下文并返回执行结果的任务
+//
+// @Description: worker 会优先调用 DoContext，返回的错误计入失败统计
+type ContextJob interface {
+	Job
+	DoContext(ctx context.Context) error
+}
+
+// TypedJob 声明任务类型的任务，用于按类型统计耗时
+type TypedJob interface {
+	Job
+	JobType() string
+}
+
+// JobFunc 函数式任务
+type JobFunc func(ctx context.Context) error
+
+// Do 执行任务
+func (f JobFunc) Do() {
+	_ = f(context.Background())
+}
+
+// DoContext 执行任务
+func (f JobFunc) DoContext(ctx context.Context) error {
+	return f(ctx)
+}
+
 // Worker 协程
 type Worker struct {
 	JobQueue chan Job  //任务队列
 	Quit     chan bool //停止当前任务
+	handle   func(Job) //任务处理函数 为空时直接调用 job.Do()
 }
 
 // NewWorker 新建一个 worker(协程)通道实例 新建一个协程
@@ -35,7 +80,11 @@ func (w *Worker) Run(wq chan chan Job) {
 			wq <- w.JobQueue //注册工作通道  到 线程池
 			select {
 			case job := <-w.JobQueue: //读到参数
-				job.Do()
+				if w.handle != nil {
+					w.handle(job)
+				} else {
+					job.Do()
+				}
 			case <-w.Quit: //终止当前任务
 				return
 			}
@@ -52,22 +101,50 @@ func (w *Worker) Stop() {
 
 // WorkerPool 线程池
 type WorkerPool struct {
-	workerLen   int      //线程池中  worker(协程)的数量
-	JobQueue    chan Job //线程池的  job 通道
-	WorkerQueue chan chan Job
-	workers     []*Worker //线程池中  worker(协程)的实例
-	Quit        chan bool //停止信号
+	workerLen    int      //线程池中  worker(协程)的数量
+	JobQueue     chan Job //线程池的  job 通道
+	WorkerQueue  chan chan Job
+	workers      []*Worker         //线程池中  worker(协程)的实例
+	Quit         chan bool         //停止信号
+	queueSize    int               //任务队列容量 0表示无缓冲
+	rejectPolicy RejectPolicy      //队列已满时的拒绝策略
+	stats        *poolStats        //运行统计
+	retryPolicy  *RetryPolicy      //默认重试策略 为空时不重试
+	laneLen      int               //按键串行通道数 0表示未开启
+	keyed        *keyedDispatcher  //按键串行调度
+	limiter      rateLimiter       //速率限制
+	semaphore    weightedSemaphore //加权信号量
+	fair         *fairQueue        //租户公平队列
+	preferFair   bool              //调度协程轮流优先取租户队列 仅调度协程访问
+	ctx          context.Context
+	cancel       context.CancelFunc
 }
 
 // NewWorkerPool 初始化worker(协程)
-func NewWorkerPool(workerLen int) *WorkerPool {
-	return &WorkerPool{
-		workerLen:   workerLen,                      //开始建立workerLen个worker(协程)
-		JobQueue:    make(chan Job),                 //工作队列 通道
-		WorkerQueue: make(chan chan Job, workerLen), //最大通道参数设为 最大协程数 workerLen协程的数量最大值
-		workers:     make([]*Worker, 0, workerLen),
-		Quit:        make(chan bool),
+//
+// @Description: 初始化线程池，默认任务队列无缓冲、拒绝策略为 AbortPolicy
+// @param workerLen worker(协程)的数量
+// @param opts 可选配置，如 WithQueueSize、WithRejectPolicy
+// @return *WorkerPool
+func NewWorkerPool(workerLen int, opts ...Option) *WorkerPool {
+	wp := &WorkerPool{
+		workerLen:    workerLen, //开始建立workerLen个worker(协程)
+		rejectPolicy: AbortPolicy,
+		fair:         newFairQueue(),
 	}
+	for _, opt := range opts {
+		opt(wp)
+	}
+	wp.JobQueue = make(chan Job, wp.queueSize)      //工作队列 通道
+	wp.WorkerQueue = make(chan chan Job, workerLen) //最大通道参数设为 最大协程数 workerLen协程的数量最大值
+	wp.workers = make([]*Worker, 0, workerLen)
+	wp.Quit = make(chan bool)
+	wp.stats = newPoolStats()
+	wp.ctx, wp.cancel = context.WithCancel(context.Background())
+	if wp.laneLen > 0 {
+		wp.keyed = newKeyedDispatcher(wp.laneLen, wp.queueSize)
+	}
+	return wp
 }
 
 // Run 运行线程池
@@ -77,37 +154,207 @@ func (wp *WorkerPool) Run() {
 	for i := 0; i < wp.workerLen; i++ {
 		//新建 workerLen个worker(协程) 并发执行，每个协程可处理一个请求
 		worker := NewWorker() //运行一个协程 将线程池 通道的参数  传递到 worker协程的通道中 进而处理这个请求
+		worker.handle = wp.runWorkerJob
 		worker.Run(wp.WorkerQueue)
 		wp.workers = append(wp.workers, worker)
 	}
+	if wp.keyed != nil {
+		wp.runLanes()
+	}
 
-	// 循环获取可用的worker,往worker中写job
-	go func() { //这是一个单独的协程 只负责保证 不断获取可用的worker
-		for {
-			select {
-			case job := <-wp.JobQueue: //读取任务
-				//尝试获取一个可用的worker作业通道。
-				//这将阻塞，直到一个worker空闲
-				worker := <-wp.WorkerQueue
-				worker <- job //将任务 分配给该协程
-			case <-wp.Quit:
-
-				// 通知所有 worker 停止
-				for _, worker := range wp.workers {
-					worker.Stop()
-				}
-				return
+	// 循环获取可用的worker,再取出任务往worker中写job
+	go wp.dispatch() //这是一个单独的协程 普通队列与租户队列都由它分配
+}
+
+// dispatch 调度协程：先等待空闲 worker，再取出任务交给它，线程池停止时通知所有 worker 停止
+//
+// 先取 worker 后取任务，调度协程不会额外持有任务，队列中最多排队 queueSize 个任务
+func (wp *WorkerPool) dispatch() {
+	defer wp.shutdown()
+	for {
+		//获取一个可用的worker作业通道。
+		//这将阻塞，直到一个worker空闲
+		var worker chan Job
+		select {
+		case worker = <-wp.WorkerQueue:
+		case <-wp.Quit:
+			wp.cancel()
+			return
+		case <-wp.ctx.Done():
+			return
+		}
+		job, ok := wp.next()
+		if !ok {
+			return
+		}
+		//在分配前等待速率令牌与信号量槽位
+		release, err := wp.acquire(job)
+		if err != nil {
+			wp.stats.failed.Add(1) //线程池已停止，任务未执行
+			return
+		}
+		select {
+		case worker <- boundJob{job: job, release: release}: //将任务 分配给该协程
+		case <-wp.ctx.Done():
+			release()
+			wp.stats.failed.Add(1) //任务已取出但未执行
+			return
+		}
+	}
+}
+
+// next 取出下一个任务，普通队列与租户队列轮流优先，都为空时阻塞等待；线程池停止时返回 false
+func (wp *WorkerPool) next() (Job, bool) {
+	for {
+		wp.preferFair = !wp.preferFair
+		if wp.preferFair {
+			if job, ok := wp.fair.pop(); ok {
+				return job, true
 			}
 		}
-	}()
+		select {
+		case job := <-wp.JobQueue:
+			return job, true
+		default:
+		}
+		if job, ok := wp.fair.pop(); ok {
+			return job, true
+		}
+		select {
+		case job := <-wp.JobQueue:
+			return job, true
+		case <-wp.fair.ready: //租户队列有新任务
+		case <-wp.Quit:
+			wp.cancel()
+			return nil, false
+		case <-wp.ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// shutdown 通知所有 worker 停止，丢弃租户队列中未调度的任务并计入失败数
+func (wp *WorkerPool) shutdown() {
+	for _, worker := range wp.workers {
+		worker.Stop()
+	}
+	wp.stats.failed.Add(int64(wp.fair.close()))
 }
 
 // Add 添加任务
+//
+// @Description: 添加任务，队列已满时阻塞等待
+// @param job 任务
 func (wp *WorkerPool) Add(job Job) {
+	if wp.offer(job) {
+		return
+	}
+	wp.stats.waiting.Add(1)
+	defer wp.stats.waiting.Add(-1)
 	wp.JobQueue <- job
 }
 
+// TryAdd 非阻塞添加任务
+//
+// @Description: 队列有空位时立即入队，队列已满时交给拒绝策略处理
+// @param job 任务
+// @return error 拒绝策略返回的错误
+func (wp *WorkerPool) TryAdd(job Job) error {
+	if wp.offer(job) {
+		return nil
+	}
+	return wp.rejectPolicy(wp, job)
+}
+
+// AddContext 添加任务，直到入队成功或 ctx 结束
+//
+// @Description: 队列已满时阻塞等待，ctx 取消或超时时返回 ctx.Err()
+// @param ctx 上下文
+// @param job 任务
+// @return error
+func (wp *WorkerPool) AddContext(ctx context.Context, job Job) error {
+	if wp.offer(job) {
+		return nil
+	}
+	wp.stats.waiting.Add(1)
+	defer wp.stats.waiting.Add(-1)
+	select {
+	case wp.JobQueue <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// offer 尝试入队，不阻塞
+func (wp *WorkerPool) offer(job Job) bool {
+	select {
+	case wp.JobQueue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
 // Stop 停止 WorkerPool
+//
+// @Description: 停止调度并取消正在执行的 ContextJob 的上下文。
+// 租户队列中尚未调度的任务被丢弃并计入失败数，之后 AddTenant 返回 ErrPoolStopped
 func (wp *WorkerPool) Stop() {
-	wp.Quit <- true
+	wp.cancel()
+}
+
+// runWorkerJob worker 执行任务，统计活跃 worker 数
+func (wp *WorkerPool) runWorkerJob(job Job) {
+	wp.stats.active.Add(1)
+	defer wp.stats.active.Add(-1)
+	if bj, ok := job.(boundJob); ok {
+		defer bj.release()
+		wp.run(bj.job)
+		return
+	}
+	wp.execute(job)
+}
+
+// execute 等待速率令牌与信号量槽位后执行任务
+func (wp *WorkerPool) execute(job Job) {
+	release, err := wp.acquire(job)
+	if err != nil {
+		//线程池已停止，任务未执行
+		wp.stats.failed.Add(1)
+		return
+	}
+	defer release()
+	wp.run(job)
+}
+
+// run 执行任务并记录结果与耗时，任务 panic 时恢复并计数
+func (wp *WorkerPool) run(job Job) {
+	var err error
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[worker_pool] job %s panic: %v", jobType(job), r)
+			wp.stats.record(jobType(job), time.Since(start), outcomePanicked)
+			return
+		}
+		if err != nil {
+			wp.stats.record(jobType(job), time.Since(start), outcomeFailed)
+			return
+		}
+		wp.stats.record(jobType(job), time.Since(start), outcomeCompleted)
+	}()
+	if cj, ok := job.(ContextJob); ok {
+		err = wp.runWithRetry(cj)
+		return
+	}
+	job.Do()
+}
+
+// jobType 获取任务类型名称
+func jobType(job Job) string {
+	if tj, ok := job.(TypedJob); ok {
+		return tj.JobType()
+	}
+	return fmt.Sprintf("%T", job)
 }